@@ -0,0 +1,11 @@
+// Package api ships the published schemas of the wire formats served to
+// clients.
+package api
+
+import _ "embed"
+
+// PriceFeedProto is the Protobuf schema of price updates, served at
+// /api/v1/schema/pricefeed.proto.
+//
+//go:embed pricefeed.proto
+var PriceFeedProto []byte
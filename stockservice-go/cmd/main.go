@@ -3,23 +3,37 @@ package main
 import (
 	"context"
 	"errors"
+	"expvar"
+	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	apischema "github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/api"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/config"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/auth"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/handlers"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/kafka"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/logging"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/notifier"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/replay"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/services"
 	"github.com/gin-gonic/gin"
 
 	"github.com/joho/godotenv"
 )
 
+// startPosition overrides KAFKA_START_POSITION for a single run, e.g. to
+// replay a day of prices.
+var startPosition = flag.String("start-position", "", "Kafka start position: committed, earliest, latest, offsets:P=O,... or timestamp:RFC3339")
+
 var (
 	cfg               *config.Config
 	router            *gin.Engine
@@ -30,11 +44,13 @@ var (
 )
 
 func main() {
+	flag.Parse()
 	cfg = loadConfig()
 	logger = logging.NewLogger()
-	notif = notifier.NewNotifier(logger)
+	registerSupportedStocks()
+	notif = initNotifier()
 
-	priceService = initKafkaConsumer()
+	priceService = initPriceService()
 
 	router = initRoutes()
 
@@ -58,40 +74,319 @@ func loadConfig() *config.Config {
 		port = ":3000"
 	}
 
-	kafkaBrokerURL := os.Getenv("KAFKA_BROKER_URL")
+	priceSource := envOrDefault("PRICE_SOURCE", priceSourceKafka)
+	kafkaBrokers := splitList(os.Getenv("KAFKA_BROKER_URL"))
 	kafkaTopic := os.Getenv("KAFKA_TOPIC")
 	kafkaGroupID := os.Getenv("KAFKA_GROUP_ID")
 
-	if kafkaBrokerURL == "" || kafkaTopic == "" || kafkaGroupID == "" {
-		panic("Kafka configuration environment variables are not set.")
+	switch priceSource {
+	case priceSourceKafka:
+		if len(kafkaBrokers) == 0 || kafkaTopic == "" || kafkaGroupID == "" {
+			panic("Kafka configuration environment variables are not set.")
+		}
+	case priceSourceFile:
+		if os.Getenv("REPLAY_PATH") == "" {
+			panic("REPLAY_PATH is not set.")
+		}
+	default:
+		panic("Unknown PRICE_SOURCE: " + priceSource)
+	}
+
+	kafkaStartPosition := os.Getenv("KAFKA_START_POSITION")
+	if *startPosition != "" {
+		kafkaStartPosition = *startPosition
 	}
 
 	return &config.Config{
-		Port:           port,
-		KafkaBrokerURL: kafkaBrokerURL,
-		KafkaTopic:     kafkaTopic,
-		KafkaGroupID:   kafkaGroupID,
+		Port:        port,
+		PriceSource: priceSource,
+		ReplayPath:  os.Getenv("REPLAY_PATH"),
+		ReplaySpeed: envFloat("REPLAY_SPEED", 1),
+
+		KafkaBrokers:    kafkaBrokers,
+		KafkaTopic:      kafkaTopic,
+		KafkaGroupID:    kafkaGroupID,
+		SupportedStocks: splitList(os.Getenv("SUPPORTED_STOCKS")),
+
+		KafkaTLS:           envBool("KAFKA_TLS_ENABLED", false),
+		KafkaTLSCAFile:     os.Getenv("KAFKA_TLS_CA_FILE"),
+		KafkaTLSCertFile:   os.Getenv("KAFKA_TLS_CERT_FILE"),
+		KafkaTLSKeyFile:    os.Getenv("KAFKA_TLS_KEY_FILE"),
+		KafkaSASLMechanism: os.Getenv("KAFKA_SASL_MECHANISM"),
+		KafkaSASLUsername:  os.Getenv("KAFKA_SASL_USERNAME"),
+		KafkaSASLPassword:  os.Getenv("KAFKA_SASL_PASSWORD"),
+
+		KafkaDeadLetterTopic: os.Getenv("KAFKA_DEAD_LETTER_TOPIC"),
+		KafkaCommitInterval:  envDuration("KAFKA_COMMIT_INTERVAL", time.Second),
+		KafkaStartPosition:   kafkaStartPosition,
+
+		FeedSequenceGapDetection: envBool("FEED_SEQUENCE_GAP_DETECTION", false),
+
+		NotifierQueueSize:  envInt("NOTIFIER_QUEUE_SIZE", 256),
+		SlowConsumerPolicy: envOrDefault("NOTIFIER_SLOW_CONSUMER_POLICY", string(notifier.DropOldest)),
+
+		AllowedOrigins: splitList(os.Getenv("ALLOWED_ORIGINS")),
+		DevMode:        envBool("DEV_MODE", false),
+		TrustedProxies: splitList(os.Getenv("TRUSTED_PROXIES")),
+
+		WSMessagesPerSecond:   envFloat("WS_MESSAGES_PER_SECOND", 10),
+		WSMessageBurst:        envInt("WS_MESSAGE_BURST", 20),
+		WSMaxConnectionsPerIP: envInt("WS_MAX_CONNECTIONS_PER_IP", 20),
+
+		AuthAPIKeys:              splitList(os.Getenv("AUTH_API_KEYS")),
+		AuthJWTHS256Secret:       os.Getenv("AUTH_JWT_HS256_SECRET"),
+		AuthJWTRS256PublicKeyPEM: os.Getenv("AUTH_JWT_RS256_PUBLIC_KEY_FILE"),
+		AuthJWTIssuer:            os.Getenv("AUTH_JWT_ISSUER"),
+		AuthJWTAudience:          os.Getenv("AUTH_JWT_AUDIENCE"),
+
+		AdminAPIKey: os.Getenv("ADMIN_API_KEY"),
+
+		DrainTimeout:  envDuration("SHUTDOWN_DRAIN_TIMEOUT", 5*time.Second),
+		ReconnectHint: os.Getenv("SHUTDOWN_RECONNECT_HINT"),
+	}
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return fallback
+}
+
+func envInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid %s: %v", key, err))
+	}
+	return parsed
+}
+
+func envFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid %s: %v", key, err))
+	}
+	return parsed
+}
+
+func envBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid %s: %v", key, err))
+	}
+	return parsed
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		panic(fmt.Sprintf("Invalid %s: %v", key, err))
+	}
+	return parsed
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func registerSupportedStocks() {
+	for _, stock := range cfg.SupportedStocks {
+		domain.Symbols.Register(domain.Stock(strings.ToUpper(stock)))
+	}
+	logger.Infof("Supported stocks: %v", domain.Symbols.Symbols())
+}
+
+func initNotifier() *notifier.Notifier {
+	policy, ok := notifier.ParseSlowConsumerPolicy(cfg.SlowConsumerPolicy)
+	if !ok {
+		panic("Unknown slow consumer policy: " + cfg.SlowConsumerPolicy)
+	}
+	return notifier.NewNotifier(logger,
+		notifier.WithQueueSize(cfg.NotifierQueueSize),
+		notifier.WithSlowConsumerPolicy(policy),
+	)
 }
 
 func initRoutes() *gin.Engine {
-	router := gin.Default()
+	// Browsers pass credentials in the query string, so the access log must
+	// not print it verbatim.
+	router := gin.New()
+	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{Formatter: handlers.AccessLogFormatter}), gin.Recovery())
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		panic("Invalid TRUSTED_PROXIES: " + err.Error())
+	}
+
+	origins, err := handlers.NewOriginPolicy(cfg.AllowedOrigins, cfg.DevMode)
+	if err != nil {
+		panic(err.Error())
+	}
+	if cfg.DevMode {
+		logger.Info("Dev mode enabled: accepting WebSocket connections from localhost origins")
+	}
+
+	liveOpts := []handlers.Option{
+		handlers.WithOriginPolicy(origins),
+		handlers.WithMessageRateLimit(cfg.WSMessagesPerSecond, cfg.WSMessageBurst),
+		handlers.WithMaxConnectionsPerIP(cfg.WSMaxConnectionsPerIP),
+	}
+	var sseOpts []handlers.SSEOption
+	var restAuth []gin.HandlerFunc
+	if authenticator := initAuthenticator(); authenticator != nil {
+		liveOpts = append(liveOpts, handlers.WithAuthenticator(authenticator))
+		sseOpts = append(sseOpts, handlers.WithSSEAuthenticator(authenticator))
+		restAuth = append(restAuth, handlers.RequireCredentials(authenticator))
+	}
 
-	livePricesHandler = handlers.NewLivePricesHandler(priceService, logger)
+	livePricesHandler = handlers.NewLivePricesHandler(priceService, logger, liveOpts...)
 	router.GET("/ws/livepricesfeed", livePricesHandler.HandleWebSocket)
 
+	ssePricesHandler := handlers.NewSSEPricesHandler(priceService, logger, sseOpts...)
+	router.GET("/sse/prices", ssePricesHandler.HandleSSE)
+
+	api := router.Group("/api/v1")
+	market := api.Group("", restAuth...)
+	pricesHandler := handlers.NewPricesHandler(priceService, logger)
+	market.GET("/prices", pricesHandler.GetPrices)
+	market.GET("/prices/:stock", pricesHandler.GetPrice)
+
+	candlesHandler := handlers.NewCandlesHandler(priceService, logger)
+	market.GET("/candles/:stock", candlesHandler.GetCandles)
+
+	api.GET("/schema/pricefeed.proto", func(ctx *gin.Context) {
+		ctx.Data(http.StatusOK, "text/plain; charset=utf-8", apischema.PriceFeedProto)
+	})
+
+	router.GET("/healthz", handlers.NewHealthHandler(priceService).Health)
+
+	if cfg.AdminAPIKey == "" {
+		logger.Info("Admin API and /debug/vars disabled: ADMIN_API_KEY is not set")
+	} else {
+		adminHandler := handlers.NewAdminHandler(notif, logger)
+		admin := router.Group("/admin", handlers.RequireAdminKey(cfg.AdminAPIKey))
+		admin.GET("/clients", adminHandler.ListClients)
+		admin.DELETE("/clients/:id", adminHandler.DisconnectClient)
+		admin.GET("/symbols", adminHandler.ListSymbols)
+		admin.POST("/symbols/:stock", adminHandler.AddSymbol)
+		admin.DELETE("/symbols/:stock", adminHandler.RemoveSymbol)
+
+		// Metrics expose memory stats and the command line, so they share
+		// the admin key.
+		router.GET("/debug/vars", handlers.RequireAdminKey(cfg.AdminAPIKey), gin.WrapH(expvar.Handler()))
+	}
+
 	return router
 }
 
-func initKafkaConsumer() *services.PriceService {
-	bitcoinPriceConsumer := kafka.NewBitcoinPriceConsumer(
-		cfg.KafkaBrokerURL,
+// initAuthenticator returns nil when no credentials are configured, leaving the
+// streaming endpoints open.
+func initAuthenticator() *auth.Authenticator {
+	var opts []auth.Option
+	for _, entry := range cfg.AuthAPIKeys {
+		key, principal, err := auth.ParseAPIKey(entry)
+		if err != nil {
+			panic(err.Error())
+		}
+		opts = append(opts, auth.WithAPIKey(key, principal))
+	}
+	if cfg.AuthJWTHS256Secret != "" {
+		opts = append(opts, auth.WithHS256Secret([]byte(cfg.AuthJWTHS256Secret)))
+	}
+	if cfg.AuthJWTRS256PublicKeyPEM != "" {
+		key, err := auth.LoadRSAPublicKey(cfg.AuthJWTRS256PublicKeyPEM)
+		if err != nil {
+			panic("Error loading RS256 public key: " + err.Error())
+		}
+		opts = append(opts, auth.WithRS256PublicKey(key))
+	}
+
+	if len(opts) == 0 {
+		logger.Info("Authentication disabled: no API keys or JWT keys configured")
+		return nil
+	}
+	if cfg.AuthJWTIssuer != "" {
+		opts = append(opts, auth.WithIssuer(cfg.AuthJWTIssuer))
+	}
+	if cfg.AuthJWTAudience != "" {
+		opts = append(opts, auth.WithAudience(cfg.AuthJWTAudience))
+	}
+	return auth.NewAuthenticator(opts...)
+}
+
+const (
+	priceSourceKafka = "kafka"
+	priceSourceFile  = "file"
+)
+
+func initPriceService() *services.PriceService {
+	var consumer ports.Consumer
+	if cfg.PriceSource == priceSourceFile {
+		logger.Infof("Replaying prices from %v at speed %v", cfg.ReplayPath, cfg.ReplaySpeed)
+		consumer = replay.NewFileConsumer(cfg.ReplayPath, logger, replay.WithSpeed(cfg.ReplaySpeed))
+	} else {
+		consumer = initKafkaConsumer()
+	}
+	var opts []services.Option
+	if cfg.FeedSequenceGapDetection {
+		opts = append(opts, services.WithSequenceGapDetection())
+	}
+	return services.NewPriceService(notif, consumer, logger, opts...)
+}
+
+func initKafkaConsumer() *kafka.BitcoinPriceConsumer {
+	position, err := kafka.ParseStartPosition(cfg.KafkaStartPosition)
+	if err != nil {
+		panic(err.Error())
+	}
+	opts := []kafka.Option{
+		kafka.WithCommitInterval(cfg.KafkaCommitInterval),
+		kafka.WithStartPosition(position),
+	}
+	if cfg.KafkaDeadLetterTopic != "" {
+		opts = append(opts, kafka.WithDeadLetterTopic(cfg.KafkaDeadLetterTopic))
+	}
+	if cfg.KafkaTLS {
+		tlsConfig, err := kafka.NewTLSConfig(cfg.KafkaTLSCAFile, cfg.KafkaTLSCertFile, cfg.KafkaTLSKeyFile)
+		if err != nil {
+			panic(err.Error())
+		}
+		opts = append(opts, kafka.WithTLS(tlsConfig))
+	}
+	if cfg.KafkaSASLMechanism != "" {
+		mechanism, err := kafka.NewSASLMechanism(cfg.KafkaSASLMechanism, cfg.KafkaSASLUsername, cfg.KafkaSASLPassword)
+		if err != nil {
+			panic(err.Error())
+		}
+		opts = append(opts, kafka.WithSASL(mechanism))
+	}
+	return kafka.NewBitcoinPriceConsumer(
+		cfg.KafkaBrokers,
 		cfg.KafkaTopic,
 		cfg.KafkaGroupID,
 		logger,
+		opts...,
 	)
-	priceService = services.NewPriceService(notif, bitcoinPriceConsumer, logger)
-	return priceService
 }
 
 func startHTTPServer() *http.Server {
@@ -116,10 +411,28 @@ func handleShutdown(cancel context.CancelFunc, srv *http.Server) {
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	defer drainCancel()
 
-	if err := srv.Shutdown(shutdownCtx); err != nil {
+	// srv.Shutdown stops accepting connections but does not track hijacked
+	// WebSocket connections, and waits on open SSE streams, so clients are
+	// closed explicitly while it runs.
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- srv.Shutdown(shutdownCtx)
+	}()
+	notif.CloseAll(drainCtx, closeReason())
+
+	if err := <-shutdownErr; err != nil {
 		logger.Errorf("Server forced to shutdown: %v", err)
 	}
 
 	cancel()
 }
+
+func closeReason() string {
+	if cfg.ReconnectHint == "" {
+		return "server shutting down"
+	}
+	return "server shutting down; " + cfg.ReconnectHint
+}
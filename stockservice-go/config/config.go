@@ -1,8 +1,82 @@
 package config
 
+import "time"
+
 type Config struct {
-	Port           string
-	KafkaBrokerURL string
-	KafkaTopic     string
-	KafkaGroupID   string
+	Port string
+	// PriceSource is "kafka" or "file". A file source replays ReplayPath, a
+	// JSON lines file or a directory of them, at ReplaySpeed times the
+	// original pace; zero replays as fast as possible.
+	PriceSource string
+	ReplayPath  string
+	ReplaySpeed float64
+
+	KafkaBrokers    []string
+	KafkaTopic      string
+	KafkaGroupID    string
+	SupportedStocks []string
+
+	// KafkaTLS enables TLS to the brokers. The CA file is added to the system
+	// pool; the client certificate and key are only needed for mutual TLS.
+	KafkaTLS         bool
+	KafkaTLSCAFile   string
+	KafkaTLSCertFile string
+	KafkaTLSKeyFile  string
+	// KafkaSASLMechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512. SASL is
+	// disabled when empty.
+	KafkaSASLMechanism string
+	KafkaSASLUsername  string
+	KafkaSASLPassword  string
+
+	// KafkaDeadLetterTopic receives messages that cannot be decoded. They are
+	// dropped when empty.
+	KafkaDeadLetterTopic string
+	// KafkaCommitInterval batches offset commits; zero commits every message
+	// synchronously.
+	KafkaCommitInterval time.Duration
+	// KafkaStartPosition is where the consumer group starts reading, as
+	// accepted by kafka.ParseStartPosition. Empty resumes from committed
+	// offsets.
+	KafkaStartPosition string
+	// FeedSequenceGapDetection reports sequence gaps to clients. Only enable
+	// it for feeds whose sequences are contiguous per product; Coinbase
+	// ticker sequences are not.
+	FeedSequenceGapDetection bool
+
+	NotifierQueueSize  int
+	SlowConsumerPolicy string
+
+	// AllowedOrigins lists browser origins allowed to open WebSockets, such as
+	// "https://app.example.com" or "https://*.example.com".
+	AllowedOrigins []string
+	// DevMode relaxes checks for local development, e.g. allowing localhost
+	// origins.
+	DevMode bool
+	// TrustedProxies lists proxy IPs or CIDRs whose X-Forwarded-For and
+	// X-Real-IP headers are believed. Empty trusts no proxy.
+	TrustedProxies []string
+
+	// WSMessagesPerSecond and WSMessageBurst bound inbound messages per
+	// connection; zero disables the limit. WSMaxConnectionsPerIP caps
+	// concurrent connections per client IP; zero disables the cap.
+	WSMessagesPerSecond   float64
+	WSMessageBurst        int
+	WSMaxConnectionsPerIP int
+
+	// AuthAPIKeys holds "subject:key:STOCK|STOCK" entries. Authentication is
+	// required as soon as an API key or a JWT key is configured.
+	AuthAPIKeys              []string
+	AuthJWTHS256Secret       string
+	AuthJWTRS256PublicKeyPEM string // path to a PEM file
+	AuthJWTIssuer            string
+	AuthJWTAudience          string
+
+	// AdminAPIKey protects the /admin API. The API is disabled when empty.
+	AdminAPIKey string
+
+	// DrainTimeout bounds how long shutdown waits for clients to be sent a
+	// close frame. ReconnectHint, when set, is appended to the close reason,
+	// e.g. "retry in 10s".
+	DrainTimeout  time.Duration
+	ReconnectHint string
 }
@@ -0,0 +1,144 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims are the JWT claims the service reads. Stocks lists the entitled
+// symbols; "*" entitles every symbol.
+type Claims struct {
+	Stocks []string `json:"stocks"`
+	jwt.RegisteredClaims
+}
+
+type apiKey struct {
+	key       []byte
+	principal domain.Principal
+}
+
+// Authenticator verifies API keys and JWTs against locally configured keys.
+type Authenticator struct {
+	apiKeys   []apiKey
+	hmacKey   []byte
+	rsaKey    *rsa.PublicKey
+	issuer    string
+	audience  string
+	jwtParser *jwt.Parser
+}
+
+type Option func(*Authenticator)
+
+// WithAPIKey accepts key as a credential for principal.
+func WithAPIKey(key string, principal domain.Principal) Option {
+	return func(a *Authenticator) {
+		a.apiKeys = append(a.apiKeys, apiKey{key: []byte(key), principal: principal})
+	}
+}
+
+// WithHS256Secret accepts JWTs signed with HS256 and secret.
+func WithHS256Secret(secret []byte) Option {
+	return func(a *Authenticator) {
+		a.hmacKey = secret
+	}
+}
+
+// WithRS256PublicKey accepts JWTs signed with RS256 by the holder of key.
+func WithRS256PublicKey(key *rsa.PublicKey) Option {
+	return func(a *Authenticator) {
+		a.rsaKey = key
+	}
+}
+
+// WithIssuer requires JWTs to carry iss.
+func WithIssuer(iss string) Option {
+	return func(a *Authenticator) {
+		a.issuer = iss
+	}
+}
+
+// WithAudience requires JWTs to list aud.
+func WithAudience(aud string) Option {
+	return func(a *Authenticator) {
+		a.audience = aud
+	}
+}
+
+func NewAuthenticator(opts ...Option) *Authenticator {
+	a := &Authenticator{}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	// Only algorithms with a configured key are accepted, so a token cannot
+	// pick a method the service was not set up for.
+	var methods []string
+	if a.hmacKey != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if a.rsaKey != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+	parserOpts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if a.issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(a.audience))
+	}
+	a.jwtParser = jwt.NewParser(parserOpts...)
+	return a
+}
+
+func (a *Authenticator) Authenticate(creds domain.Credentials) (*domain.Principal, error) {
+	switch {
+	case creds.APIKey != "":
+		return a.authenticateAPIKey(creds.APIKey)
+	case creds.Token != "":
+		return a.authenticateToken(creds.Token)
+	default:
+		return nil, domain.ErrMissingCredentials
+	}
+}
+
+func (a *Authenticator) authenticateAPIKey(key string) (*domain.Principal, error) {
+	for _, candidate := range a.apiKeys {
+		if subtle.ConstantTimeCompare(candidate.key, []byte(key)) == 1 {
+			principal := candidate.principal
+			return &principal, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: unknown API key", domain.ErrInvalidCredentials)
+}
+
+func (a *Authenticator) authenticateToken(raw string) (*domain.Principal, error) {
+	if a.hmacKey == nil && a.rsaKey == nil {
+		return nil, fmt.Errorf("%w: tokens are not accepted", domain.ErrInvalidCredentials)
+	}
+
+	var claims Claims
+	_, err := a.jwtParser.ParseWithClaims(raw, &claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method {
+		case jwt.SigningMethodHS256:
+			return a.hmacKey, nil
+		case jwt.SigningMethodRS256:
+			return a.rsaKey, nil
+		default:
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidCredentials, err)
+	}
+
+	principal := &domain.Principal{Subject: claims.Subject}
+	for _, stock := range claims.Stocks {
+		principal.Stocks = append(principal.Stocks, domain.Stock(strings.ToUpper(stock)))
+	}
+	return principal, nil
+}
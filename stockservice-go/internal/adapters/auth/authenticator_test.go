@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+var hmacSecret = []byte("test-secret")
+
+func signedToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims Claims) string {
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	assert.NoError(t, err)
+	return token
+}
+
+func validClaims() Claims {
+	return Claims{
+		Stocks: []string{"BTC-USD"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "alice",
+			Issuer:    "bitcoin-pulse",
+			Audience:  jwt.ClaimStrings{"stockservice"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestAuthenticator_APIKey(t *testing.T) {
+	authenticator := NewAuthenticator(WithAPIKey("k3y", domain.Principal{Subject: "dashboard", Stocks: []domain.Stock{domain.AllStocks}}))
+
+	principal, err := authenticator.Authenticate(domain.Credentials{APIKey: "k3y"})
+	assert.NoError(t, err)
+	assert.Equal(t, "dashboard", principal.Subject)
+	assert.True(t, principal.Entitled(domain.StockBitcoin))
+
+	_, err = authenticator.Authenticate(domain.Credentials{APIKey: "wrong"})
+	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+
+	_, err = authenticator.Authenticate(domain.Credentials{})
+	assert.ErrorIs(t, err, domain.ErrMissingCredentials)
+}
+
+func TestAuthenticator_HS256(t *testing.T) {
+	authenticator := NewAuthenticator(WithHS256Secret(hmacSecret), WithIssuer("bitcoin-pulse"), WithAudience("stockservice"))
+
+	principal, err := authenticator.Authenticate(domain.Credentials{Token: signedToken(t, jwt.SigningMethodHS256, hmacSecret, validClaims())})
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Principal{Subject: "alice", Stocks: []domain.Stock{domain.StockBitcoin}}, principal)
+	assert.False(t, principal.Entitled("ETH-USD"))
+
+	// Symbols in the stocks claim are matched like API key entitlements.
+	lowercase := validClaims()
+	lowercase.Stocks = []string{"btc-usd"}
+	principal, err = authenticator.Authenticate(domain.Credentials{Token: signedToken(t, jwt.SigningMethodHS256, hmacSecret, lowercase)})
+	assert.NoError(t, err)
+	assert.True(t, principal.Entitled(domain.StockBitcoin))
+}
+
+func TestAuthenticator_RS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	authenticator := NewAuthenticator(WithRS256PublicKey(&key.PublicKey))
+
+	principal, err := authenticator.Authenticate(domain.Credentials{Token: signedToken(t, jwt.SigningMethodRS256, key, validClaims())})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", principal.Subject)
+
+	// An HS256 token must not be accepted when only an RSA key is configured.
+	_, err = authenticator.Authenticate(domain.Credentials{Token: signedToken(t, jwt.SigningMethodHS256, hmacSecret, validClaims())})
+	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+}
+
+func TestAuthenticator_RejectsInvalidTokens(t *testing.T) {
+	authenticator := NewAuthenticator(WithHS256Secret(hmacSecret), WithIssuer("bitcoin-pulse"), WithAudience("stockservice"))
+
+	expired := validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	noExpiry := validClaims()
+	noExpiry.ExpiresAt = nil
+	otherIssuer := validClaims()
+	otherIssuer.Issuer = "someone-else"
+	otherAudience := validClaims()
+	otherAudience.Audience = jwt.ClaimStrings{"another-service"}
+
+	tokens := map[string]string{
+		"expired":        signedToken(t, jwt.SigningMethodHS256, hmacSecret, expired),
+		"no expiry":      signedToken(t, jwt.SigningMethodHS256, hmacSecret, noExpiry),
+		"wrong issuer":   signedToken(t, jwt.SigningMethodHS256, hmacSecret, otherIssuer),
+		"wrong audience": signedToken(t, jwt.SigningMethodHS256, hmacSecret, otherAudience),
+		"wrong secret":   signedToken(t, jwt.SigningMethodHS256, []byte("other-secret"), validClaims()),
+		"garbage":        "not.a.jwt",
+	}
+	for name, token := range tokens {
+		_, err := authenticator.Authenticate(domain.Credentials{Token: token})
+		assert.ErrorIs(t, err, domain.ErrInvalidCredentials, name)
+	}
+}
+
+func TestAuthenticator_TokensDisabledWithoutJWTKeys(t *testing.T) {
+	authenticator := NewAuthenticator(WithAPIKey("k3y", domain.Principal{Subject: "dashboard"}))
+
+	_, err := authenticator.Authenticate(domain.Credentials{Token: signedToken(t, jwt.SigningMethodHS256, hmacSecret, validClaims())})
+	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+}
+
+func TestParseAPIKey(t *testing.T) {
+	key, principal, err := ParseAPIKey("dashboard:k3y:btc-usd|ETH-USD")
+	assert.NoError(t, err)
+	assert.Equal(t, "k3y", key)
+	assert.Equal(t, domain.Principal{Subject: "dashboard", Stocks: []domain.Stock{"BTC-USD", "ETH-USD"}}, principal)
+
+	for _, entry := range []string{"k3y", "dashboard:k3y", ":k3y:*", "dashboard::*"} {
+		_, _, err := ParseAPIKey(entry)
+		assert.Error(t, err, entry)
+	}
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ParseAPIKey parses a "subject:key:STOCK|STOCK" entry. Use "*" as the stock
+// list to entitle the key to every symbol.
+func ParseAPIKey(entry string) (string, domain.Principal, error) {
+	parts := strings.SplitN(entry, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", domain.Principal{}, fmt.Errorf("invalid API key entry %q: expected subject:key:STOCK|STOCK", entry)
+	}
+
+	principal := domain.Principal{Subject: parts[0]}
+	for _, stock := range strings.Split(parts[2], "|") {
+		if stock = strings.TrimSpace(stock); stock != "" {
+			principal.Stocks = append(principal.Stocks, domain.Stock(strings.ToUpper(stock)))
+		}
+	}
+	return parts[1], principal, nil
+}
+
+// LoadRSAPublicKey reads a PEM-encoded RSA public key.
+func LoadRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return jwt.ParseRSAPublicKeyFromPEM(data)
+}
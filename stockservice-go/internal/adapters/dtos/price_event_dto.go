@@ -1,6 +1,7 @@
 package dtos
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -8,6 +9,10 @@ import (
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 )
 
+// ErrUnsupportedStock reports an event for a product the service does not
+// serve. Such events are well formed and can simply be skipped.
+var ErrUnsupportedStock = errors.New("unsupported stock")
+
 type PriceEventDTO struct {
 	Type        string `json:"type"`
 	Sequence    int64  `json:"sequence"`
@@ -76,8 +81,11 @@ func ToPriceEvent(dto *PriceEventDTO) (*domain.PriceEvent, error) {
 		return f, nil
 	}
 
+	if dto.ProductID == "" {
+		return nil, fmt.Errorf("missing product_id")
+	}
 	if !domain.IsSupportedStock(dto.ProductID) {
-		return nil, fmt.Errorf("unsupported stock: %v", dto.ProductID)
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedStock, dto.ProductID)
 	}
 	productID := domain.Stock(dto.ProductID)
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/dtos"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/testutils"
 	"github.com/stretchr/testify/assert"
 )
@@ -58,3 +59,37 @@ func TestToPriceEvent_ErrorParsingTime(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "error parsing Time")
 }
+
+func TestToPriceEvent_RegisteredStock(t *testing.T) {
+	domain.Symbols.Register("ETH-USD")
+	defer domain.Symbols.Unregister("ETH-USD")
+
+	dto := testutils.CreateValidPriceEventDTO()
+	dto.ProductID = "ETH-USD"
+
+	event, err := dtos.ToPriceEvent(dto)
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Stock("ETH-USD"), event.ProductID)
+}
+
+func TestToPriceEvent_UnsupportedStock(t *testing.T) {
+	dto := testutils.CreateValidPriceEventDTO()
+	dto.ProductID = "XRP-USD"
+
+	event, err := dtos.ToPriceEvent(dto)
+
+	assert.Nil(t, event)
+	assert.ErrorIs(t, err, dtos.ErrUnsupportedStock)
+}
+
+func TestToPriceEvent_MissingProductID(t *testing.T) {
+	dto := testutils.CreateValidPriceEventDTO()
+	dto.ProductID = ""
+
+	event, err := dtos.ToPriceEvent(dto)
+
+	assert.Nil(t, event)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, dtos.ErrUnsupportedStock)
+}
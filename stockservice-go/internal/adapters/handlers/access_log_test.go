@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactCredentials(t *testing.T) {
+	tests := map[string]string{
+		"/sse/prices":                                       "/sse/prices",
+		"/sse/prices?stocks=BTC-USD":                        "/sse/prices?stocks=BTC-USD",
+		"/ws/livepricesfeed?api_key=secret":                 "/ws/livepricesfeed?api_key=REDACTED",
+		"/sse/prices?stocks=BTC-USD&access_token=a.b.c&x=1": "/sse/prices?stocks=BTC-USD&access_token=REDACTED&x=1",
+		"/sse/prices?api%5Fkey=secret&api_key":              "/sse/prices?api%5Fkey=REDACTED&api_key=REDACTED",
+	}
+	for path, expected := range tests {
+		assert.Equal(t, expected, redactCredentials(path), path)
+	}
+}
+
+func TestAccessLogFormatter_RedactsCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	router := gin.New()
+	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{Formatter: AccessLogFormatter, Output: &logs}))
+	router.GET("/sse/prices", func(ctx *gin.Context) {
+		assert.Equal(t, "secret", ctx.Query("api_key"), "handlers still see the credentials")
+		ctx.Status(http.StatusNoContent)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/sse/prices?stocks=BTC-USD&api_key=secret", nil))
+
+	assert.Contains(t, logs.String(), "/sse/prices?stocks=BTC-USD&api_key=REDACTED")
+	assert.NotContains(t, logs.String(), "secret")
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler lets operators inspect and disconnect streaming clients and
+// manage the supported symbols.
+type AdminHandler struct {
+	clients ports.ClientRegistry
+	logger  ports.Logger
+}
+
+type clientsResponse struct {
+	Clients []domain.ClientInfo `json:"clients"`
+}
+
+type symbolSubscribers struct {
+	Stock       domain.Stock `json:"stock"`
+	Subscribers int          `json:"subscribers"`
+}
+
+type symbolsResponse struct {
+	Symbols []symbolSubscribers `json:"symbols"`
+}
+
+func NewAdminHandler(clients ports.ClientRegistry, logger ports.Logger) *AdminHandler {
+	return &AdminHandler{
+		clients: clients,
+		logger:  logger,
+	}
+}
+
+// RequireAdminKey rejects requests that do not carry key as an
+// "Authorization: Bearer" token.
+func RequireAdminKey(key string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		bearer, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(bearer)), []byte(key)) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, unauthorized())
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// ListClients serves GET /admin/clients.
+func (h *AdminHandler) ListClients(ctx *gin.Context) {
+	clients := h.clients.Clients()
+	if clients == nil {
+		clients = []domain.ClientInfo{}
+	}
+	ctx.JSON(http.StatusOK, clientsResponse{Clients: clients})
+}
+
+// ListSymbols serves GET /admin/symbols with the ticker subscriber count of
+// every supported stock.
+func (h *AdminHandler) ListSymbols(ctx *gin.Context) {
+	counts := h.clients.SubscriberCounts()
+	for _, stock := range domain.Symbols.Symbols() {
+		if _, ok := counts[stock]; !ok {
+			counts[stock] = 0
+		}
+	}
+
+	symbols := make([]symbolSubscribers, 0, len(counts))
+	for stock, count := range counts {
+		symbols = append(symbols, symbolSubscribers{Stock: stock, Subscribers: count})
+	}
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Stock < symbols[j].Stock })
+	ctx.JSON(http.StatusOK, symbolsResponse{Symbols: symbols})
+}
+
+// AddSymbol serves POST /admin/symbols/:stock. Clients can subscribe to the
+// symbol as soon as it is registered.
+func (h *AdminHandler) AddSymbol(ctx *gin.Context) {
+	stock := domain.Stock(strings.ToUpper(strings.TrimSpace(ctx.Param("stock"))))
+	if stock == "" {
+		ctx.JSON(http.StatusBadRequest, domain.ErrorMessage{
+			Type:    "error",
+			Code:    domain.ErrCodeUnsupportedStock,
+			Message: "Missing stock symbol",
+		})
+		return
+	}
+	domain.Symbols.Register(stock)
+	h.logger.Infof("Operator registered symbol %v", stock)
+	ctx.Status(http.StatusNoContent)
+}
+
+// RemoveSymbol serves DELETE /admin/symbols/:stock. New subscriptions to the
+// symbol are rejected, existing ones are dropped and their clients told, and
+// its upstream events are skipped from then on.
+func (h *AdminHandler) RemoveSymbol(ctx *gin.Context) {
+	stock := domain.Stock(strings.ToUpper(strings.TrimSpace(ctx.Param("stock"))))
+	if stock == domain.StockBitcoin {
+		ctx.JSON(http.StatusConflict, domain.ErrorMessage{
+			Type:    "error",
+			Code:    domain.ErrCodeProtectedStock,
+			Message: string(stock) + " is always supported",
+		})
+		return
+	}
+	if !domain.Symbols.IsSupported(stock) {
+		ctx.JSON(http.StatusNotFound, domain.ErrorMessage{
+			Type:    "error",
+			Code:    domain.ErrCodeUnsupportedStock,
+			Message: "Unsupported stock symbol " + string(stock),
+		})
+		return
+	}
+	domain.Symbols.Unregister(stock)
+	dropped := h.clients.DropSymbol(stock)
+	h.logger.Infof("Operator unregistered symbol %v, dropping the subscriptions of %d clients", stock, dropped)
+	ctx.Status(http.StatusNoContent)
+}
+
+// DisconnectClient serves DELETE /admin/clients/:id.
+func (h *AdminHandler) DisconnectClient(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if err := h.clients.Disconnect(id); err != nil {
+		if errors.Is(err, domain.ErrUnknownClient) {
+			ctx.JSON(http.StatusNotFound, domain.ErrorMessage{
+				Type:    "error",
+				Code:    domain.ErrCodeUnknownClient,
+				Message: "No connected client " + id,
+			})
+			return
+		}
+		h.logger.Errorf("Error disconnecting client %v: %v", id, err)
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+const testAdminKey = "admin-secret"
+
+func setupAdminRouter(t *testing.T) (*gomock.Controller, *mocks.MockClientRegistry, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	registry := mocks.NewMockClientRegistry(ctrl)
+	handler := NewAdminHandler(registry, &mocks.StubLogger{})
+
+	router := gin.New()
+	admin := router.Group("/admin", RequireAdminKey(testAdminKey))
+	admin.GET("/clients", handler.ListClients)
+	admin.DELETE("/clients/:id", handler.DisconnectClient)
+	admin.GET("/symbols", handler.ListSymbols)
+	admin.POST("/symbols/:stock", handler.AddSymbol)
+	admin.DELETE("/symbols/:stock", handler.RemoveSymbol)
+	return ctrl, registry, router
+}
+
+func serveAdmin(router *gin.Engine, method, target, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdmin_RequiresKey(t *testing.T) {
+	ctrl, _, router := setupAdminRouter(t)
+	defer ctrl.Finish()
+
+	for _, key := range []string{"", "wrong"} {
+		w := serveAdmin(router, http.MethodGet, "/admin/clients", key)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), string(domain.ErrCodeUnauthorized))
+	}
+}
+
+func TestAdmin_ListClients(t *testing.T) {
+	ctrl, registry, router := setupAdminRouter(t)
+	defer ctrl.Finish()
+
+	clients := []domain.ClientInfo{{
+		ID:            "client-1",
+		RemoteAddr:    "127.0.0.1:12345",
+		ConnectedAt:   time.Date(2024, 4, 27, 14, 0, 0, 0, time.UTC),
+		Subscriptions: []domain.Stock{domain.StockBitcoin},
+		QueueDepth:    3,
+	}}
+	registry.EXPECT().Clients().Return(clients)
+
+	w := serveAdmin(router, http.MethodGet, "/admin/clients", testAdminKey)
+
+	expected, err := json.Marshal(clientsResponse{Clients: clients})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(expected), w.Body.String())
+}
+
+func TestAdmin_ListSymbolsIncludesIdleSymbols(t *testing.T) {
+	ctrl, registry, router := setupAdminRouter(t)
+	defer ctrl.Finish()
+
+	registry.EXPECT().SubscriberCounts().Return(map[domain.Stock]int{"ETH-USD": 2})
+
+	w := serveAdmin(router, http.MethodGet, "/admin/symbols", testAdminKey)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"symbols":[{"stock":"BTC-USD","subscribers":0},{"stock":"ETH-USD","subscribers":2}]}`, w.Body.String())
+}
+
+func TestAdmin_DisconnectClient(t *testing.T) {
+	ctrl, registry, router := setupAdminRouter(t)
+	defer ctrl.Finish()
+
+	registry.EXPECT().Disconnect("client-1").Return(nil)
+
+	w := serveAdmin(router, http.MethodDelete, "/admin/clients/client-1", testAdminKey)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestAdmin_DisconnectUnknownClient(t *testing.T) {
+	ctrl, registry, router := setupAdminRouter(t)
+	defer ctrl.Finish()
+
+	registry.EXPECT().Disconnect("client-9").Return(fmt.Errorf("%w: client-9", domain.ErrUnknownClient))
+
+	w := serveAdmin(router, http.MethodDelete, "/admin/clients/client-9", testAdminKey)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeUnknownClient))
+}
+
+func TestAdmin_AddAndRemoveSymbol(t *testing.T) {
+	ctrl, registry, router := setupAdminRouter(t)
+	defer ctrl.Finish()
+	const stock domain.Stock = "DOGE-USD"
+	t.Cleanup(func() { domain.Symbols.Unregister(stock) })
+
+	w := serveAdmin(router, http.MethodPost, "/admin/symbols/doge-usd", testAdminKey)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.True(t, domain.Symbols.IsSupported(stock))
+
+	registry.EXPECT().DropSymbol(stock).Return(1)
+	w = serveAdmin(router, http.MethodDelete, "/admin/symbols/DOGE-USD", testAdminKey)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.False(t, domain.Symbols.IsSupported(stock))
+
+	w = serveAdmin(router, http.MethodDelete, "/admin/symbols/DOGE-USD", testAdminKey)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeUnsupportedStock))
+}
+
+func TestAdmin_RemoveSymbolKeepsBitcoin(t *testing.T) {
+	ctrl, _, router := setupAdminRouter(t)
+	defer ctrl.Finish()
+
+	w := serveAdmin(router, http.MethodDelete, "/admin/symbols/BTC-USD", testAdminKey)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeProtectedStock))
+	assert.True(t, domain.Symbols.IsSupported(domain.StockBitcoin))
+}
+
+func TestAdmin_AddSymbolRequiresKey(t *testing.T) {
+	ctrl, _, router := setupAdminRouter(t)
+	defer ctrl.Finish()
+
+	w := serveAdmin(router, http.MethodPost, "/admin/symbols/DOGE-USD", "wrong")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, domain.Symbols.IsSupported("DOGE-USD"))
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+// CandlesHandler serves aggregated OHLCV candles over plain HTTP.
+type CandlesHandler struct {
+	priceService ports.PriceService
+	logger       ports.Logger
+}
+
+func NewCandlesHandler(ps ports.PriceService, logger ports.Logger) *CandlesHandler {
+	return &CandlesHandler{
+		priceService: ps,
+		logger:       logger,
+	}
+}
+
+// GetCandles serves GET /api/v1/candles/:stock?interval=1m&from=&to=. from
+// and to accept RFC 3339 timestamps or Unix seconds and may be omitted.
+func (h *CandlesHandler) GetCandles(ctx *gin.Context) {
+	stock := domain.Stock(ctx.Param("stock"))
+	if !domain.IsSupportedStock(string(stock)) {
+		h.badRequest(ctx, domain.ErrCodeUnsupportedStock, "Unsupported stock symbol: "+string(stock))
+		return
+	}
+	if !principalFrom(ctx).Entitled(stock) {
+		ctx.JSON(http.StatusForbidden, notEntitled(stock))
+		return
+	}
+
+	interval, ok := domain.ParseCandleInterval(ctx.DefaultQuery("interval", string(domain.Interval1m)))
+	if !ok {
+		h.badRequest(ctx, domain.ErrCodeInvalidInterval, "Unsupported candle interval: "+ctx.Query("interval"))
+		return
+	}
+
+	from, err := parseTimeParam(ctx.Query("from"))
+	if err != nil {
+		h.badRequest(ctx, domain.ErrCodeInvalidMessage, "Invalid from: "+err.Error())
+		return
+	}
+	to, err := parseTimeParam(ctx.Query("to"))
+	if err != nil {
+		h.badRequest(ctx, domain.ErrCodeInvalidMessage, "Invalid to: "+err.Error())
+		return
+	}
+
+	ctx.JSON(http.StatusOK, h.priceService.Candles(stock, interval, from, to))
+}
+
+func (h *CandlesHandler) badRequest(ctx *gin.Context, code domain.ErrorCode, message string) {
+	ctx.JSON(http.StatusBadRequest, domain.ErrorMessage{
+		Type:    "error",
+		Code:    code,
+		Message: message,
+	})
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
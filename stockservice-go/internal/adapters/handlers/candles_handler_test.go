@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setupCandlesRouter(t *testing.T) (*gomock.Controller, *mocks.MockPriceService, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	mockPriceService := mocks.NewMockPriceService(ctrl)
+	handler := NewCandlesHandler(mockPriceService, &mocks.StubLogger{})
+
+	router := gin.New()
+	router.GET("/api/v1/candles/:stock", handler.GetCandles)
+	return ctrl, mockPriceService, router
+}
+
+func TestGetCandles(t *testing.T) {
+	ctrl, mockPriceService, router := setupCandlesRouter(t)
+	defer ctrl.Finish()
+
+	from := time.Date(2024, 4, 27, 14, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	candles := []domain.Candle{{
+		Stock:    domain.StockBitcoin,
+		Interval: domain.Interval5m,
+		Start:    from,
+		Open:     100,
+		High:     110,
+		Low:      90,
+		Close:    105,
+		Volume:   3,
+		Closed:   true,
+	}}
+	mockPriceService.EXPECT().Candles(domain.StockBitcoin, domain.Interval5m, from, to).Return(candles)
+
+	w := serve(router, "/api/v1/candles/BTC-USD?interval=5m&from=2024-04-27T14:00:00Z&to=1714230000")
+
+	expected, err := json.Marshal(candles)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(expected), w.Body.String())
+}
+
+func TestGetCandles_DefaultsToOneMinute(t *testing.T) {
+	ctrl, mockPriceService, router := setupCandlesRouter(t)
+	defer ctrl.Finish()
+
+	mockPriceService.EXPECT().Candles(domain.StockBitcoin, domain.Interval1m, time.Time{}, time.Time{}).Return([]domain.Candle{})
+
+	w := serve(router, "/api/v1/candles/BTC-USD")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+}
+
+func TestGetCandles_InvalidRequests(t *testing.T) {
+	ctrl, _, router := setupCandlesRouter(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		target string
+		code   domain.ErrorCode
+	}{
+		{"/api/v1/candles/DOGE-USD", domain.ErrCodeUnsupportedStock},
+		{"/api/v1/candles/BTC-USD?interval=2m", domain.ErrCodeInvalidInterval},
+		{"/api/v1/candles/BTC-USD?from=yesterday", domain.ErrCodeInvalidMessage},
+		{"/api/v1/candles/BTC-USD?to=tomorrow", domain.ErrCodeInvalidMessage},
+	}
+	for _, tt := range tests {
+		w := serve(router, tt.target)
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.target)
+		assert.Contains(t, w.Body.String(), string(tt.code), tt.target)
+	}
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+// Query parameters that carry credentials. They are redacted from access logs
+// by AccessLogFormatter.
+const (
+	apiKeyParam      = "api_key"
+	accessTokenParam = "access_token"
+)
+
+// credentialsFrom reads an API key from X-API-Key or ?api_key=, and a JWT from
+// an "Authorization: Bearer" header or ?access_token=. Browsers cannot set
+// headers on WebSocket or EventSource requests, hence the query parameters.
+func credentialsFrom(r *http.Request) domain.Credentials {
+	creds := domain.Credentials{
+		APIKey: r.Header.Get("X-API-Key"),
+		Token:  r.URL.Query().Get(accessTokenParam),
+	}
+	if creds.APIKey == "" {
+		creds.APIKey = r.URL.Query().Get(apiKeyParam)
+	}
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		creds.Token = strings.TrimSpace(bearer)
+	}
+	return creds
+}
+
+// authenticate returns the principal behind r, or domain.Anonymous when
+// authentication is disabled.
+func authenticate(authenticator ports.Authenticator, r *http.Request) (*domain.Principal, error) {
+	if authenticator == nil {
+		return domain.Anonymous, nil
+	}
+	return authenticator.Authenticate(credentialsFrom(r))
+}
+
+// principalKey holds the *domain.Principal set by RequireCredentials.
+const principalKey = "principal"
+
+// RequireCredentials authenticates REST requests the same way as the
+// streaming endpoints, and records who made them for entitlement checks.
+func RequireCredentials(authenticator ports.Authenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		principal, err := authenticate(authenticator, ctx.Request)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, unauthorized())
+			return
+		}
+		ctx.Set(principalKey, principal)
+		ctx.Next()
+	}
+}
+
+// principalFrom returns the principal recorded by RequireCredentials, or
+// domain.Anonymous on routes without authentication.
+func principalFrom(ctx *gin.Context) *domain.Principal {
+	if principal, ok := ctx.Value(principalKey).(*domain.Principal); ok {
+		return principal
+	}
+	return domain.Anonymous
+}
+
+func notEntitled(stock domain.Stock) domain.ErrorMessage {
+	return domain.ErrorMessage{
+		Type:    "error",
+		Code:    domain.ErrCodeNotEntitled,
+		Message: "Not entitled to " + string(stock),
+	}
+}
+
+func unauthorized() domain.ErrorMessage {
+	return domain.ErrorMessage{
+		Type:    "error",
+		Code:    domain.ErrCodeUnauthorized,
+		Message: "Missing or invalid credentials",
+	}
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCredentialsFrom(t *testing.T) {
+	headers := httptest.NewRequest(http.MethodGet, "/ws/livepricesfeed", nil)
+	headers.Header.Set("X-API-Key", "k3y")
+	headers.Header.Set("Authorization", "Bearer t0ken")
+	assert.Equal(t, domain.Credentials{APIKey: "k3y", Token: "t0ken"}, credentialsFrom(headers))
+
+	query := httptest.NewRequest(http.MethodGet, "/ws/livepricesfeed?api_key=k3y&access_token=t0ken", nil)
+	assert.Equal(t, domain.Credentials{APIKey: "k3y", Token: "t0ken"}, credentialsFrom(query))
+
+	basic := httptest.NewRequest(http.MethodGet, "/ws/livepricesfeed", nil)
+	basic.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	assert.Equal(t, domain.Credentials{}, credentialsFrom(basic))
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler reports whether the service is receiving prices.
+type HealthHandler struct {
+	feed ports.FeedHealth
+}
+
+type healthResponse struct {
+	Status string            `json:"status"`
+	Feed   domain.FeedStatus `json:"feed"`
+}
+
+func NewHealthHandler(feed ports.FeedHealth) *HealthHandler {
+	return &HealthHandler{feed: feed}
+}
+
+// Health serves GET /healthz. A degraded feed still answers 200 since reads
+// are being retried; a disconnected one answers 503.
+func (h *HealthHandler) Health(ctx *gin.Context) {
+	feed := h.feed.FeedStatus()
+	switch feed {
+	case domain.StatusConnected:
+		ctx.JSON(http.StatusOK, healthResponse{Status: "ok", Feed: feed})
+	case domain.StatusDegraded:
+		ctx.JSON(http.StatusOK, healthResponse{Status: "degraded", Feed: feed})
+	default:
+		ctx.JSON(http.StatusServiceUnavailable, healthResponse{Status: "unavailable", Feed: feed})
+	}
+}
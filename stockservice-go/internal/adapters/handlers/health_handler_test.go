@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		feed   domain.FeedStatus
+		code   int
+		status string
+	}{
+		{domain.StatusConnected, http.StatusOK, "ok"},
+		{domain.StatusDegraded, http.StatusOK, "degraded"},
+		{domain.StatusDisconnected, http.StatusServiceUnavailable, "unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.feed), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			feed := mocks.NewMockFeedHealth(ctrl)
+			feed.EXPECT().FeedStatus().Return(tt.feed)
+
+			router := gin.New()
+			router.GET("/healthz", NewHealthHandler(feed).Health)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+			assert.Equal(t, tt.code, w.Code)
+			var body healthResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, healthResponse{Status: tt.status, Feed: tt.feed}, body)
+		})
+	}
+}
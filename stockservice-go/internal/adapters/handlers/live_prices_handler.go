@@ -2,116 +2,411 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"expvar"
+	"net"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
-	"go.uber.org/zap"
 )
 
 const (
 	maxMessageSize = 2048
-	writeWait      = 10 // seconds
-	pongWait       = 60 // seconds
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin:     checkOrigin,
+// reapedConnections counts connections closed because they stopped answering
+// pings. It is published at /debug/vars.
+var reapedConnections = expvar.NewInt("ws_reaped_connections")
+
+func wireSubprotocols() []string {
+	protocols := make([]string, 0, len(domain.WireFormats))
+	for _, format := range domain.WireFormats {
+		protocols = append(protocols, string(format))
+	}
+	return protocols
 }
 
 type LivePricesHandler struct {
-	priceService ports.PriceService
-	logger       *zap.Logger
-	mu           sync.Mutex
-	clients      map[ports.WebSocketConn]bool
+	priceService  ports.PriceService
+	logger        ports.Logger
+	upgrader      websocket.Upgrader
+	origins       *OriginPolicy
+	authenticator ports.Authenticator
+	// messageRate and messageBurst bound inbound messages per connection;
+	// a zero rate disables the limit.
+	messageRate  float64
+	messageBurst int
+	connLimiter  *connLimiter
+	// pingPeriod is how often the server pings; a connection that sends
+	// nothing, not even a pong, for pongWait is reaped.
+	pingPeriod time.Duration
+	pongWait   time.Duration
+	mu         sync.Mutex
+	clients    map[ports.WebSocketConn]*domain.Principal
+}
+
+type Option func(*LivePricesHandler)
+
+// WithOriginPolicy sets which browser origins may connect. Without it only
+// same-origin pages and non-browser clients are accepted.
+func WithOriginPolicy(policy *OriginPolicy) Option {
+	return func(h *LivePricesHandler) {
+		h.origins = policy
+	}
+}
+
+// WithAuthenticator requires clients to authenticate before upgrading and
+// limits their subscriptions to their entitlements.
+func WithAuthenticator(authenticator ports.Authenticator) Option {
+	return func(h *LivePricesHandler) {
+		h.authenticator = authenticator
+	}
+}
+
+// WithMessageRateLimit allows each connection burst messages at once and rate
+// messages per second after that. Clients going over are closed with 1008.
+func WithMessageRateLimit(rate float64, burst int) Option {
+	return func(h *LivePricesHandler) {
+		h.messageRate = rate
+		h.messageBurst = max(burst, 1)
+	}
 }
 
-func NewLivePricesHandler(ps ports.PriceService, logger *zap.Logger) *LivePricesHandler {
-	return &LivePricesHandler{
+// WithMaxConnectionsPerIP caps concurrent connections per client IP, as
+// reported by gin's ClientIP. Extra connections are closed with 1013.
+func WithMaxConnectionsPerIP(limit int) Option {
+	return func(h *LivePricesHandler) {
+		h.connLimiter = newConnLimiter(limit)
+	}
+}
+
+// WithHeartbeat overrides how often connections are pinged and how long they
+// may stay silent before being reaped. pingPeriod must be below pongWait.
+func WithHeartbeat(pingPeriod, pongWait time.Duration) Option {
+	return func(h *LivePricesHandler) {
+		h.pingPeriod = pingPeriod
+		h.pongWait = pongWait
+	}
+}
+
+func NewLivePricesHandler(ps ports.PriceService, logger ports.Logger, opts ...Option) *LivePricesHandler {
+	h := &LivePricesHandler{
 		priceService: ps,
 		logger:       logger,
-		clients:      make(map[ports.WebSocketConn]bool),
+		origins:      &OriginPolicy{},
+		connLimiter:  newConnLimiter(0),
+		pingPeriod:   pingPeriod,
+		pongWait:     pongWait,
+		clients:      make(map[ports.WebSocketConn]*domain.Principal),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     h.checkOrigin,
+		Subprotocols:    wireSubprotocols(),
 	}
+	return h
 }
 
-func checkOrigin(r *http.Request) bool {
-	origin := r.Header.Get("Origin")
-	// TODO: Implement proper origin validation against allowed domains
-	return origin == "https://yourdomain.com" // Example
+func (h *LivePricesHandler) checkOrigin(r *http.Request) bool {
+	if h.origins.Allowed(r) {
+		return true
+	}
+	h.logger.Infof("Rejected WebSocket origin %q from %v", r.Header.Get("Origin"), r.RemoteAddr)
+	return false
 }
 
 func (h *LivePricesHandler) HandleWebSocket(ctx *gin.Context) {
-	ws, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	principal, err := authenticate(h.authenticator, ctx.Request)
 	if err != nil {
-		h.logger.Error("WebSocket upgrade failed", zap.Error(err))
+		h.logger.Infof("Rejected WebSocket connection from %v: %v", ctx.Request.RemoteAddr, err)
+		ctx.JSON(http.StatusUnauthorized, unauthorized())
 		return
 	}
 
-	conn := ws.(ports.WebSocketConn)
+	ws, err := h.upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		h.logger.Errorf("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	// The cap is enforced after the upgrade so browsers see a close code
+	// rather than an opaque handshake failure.
+	ip := ctx.ClientIP()
+	if !h.connLimiter.acquire(ip) {
+		h.logger.Infof("Rejected WebSocket connection from %v: too many connections", ip)
+		h.closeWithCode(ws, websocket.CloseTryAgainLater, "too many connections")
+		if err := ws.Close(); err != nil {
+			h.logger.Errorf("Error closing WebSocket: %v", err)
+		}
+		return
+	}
+	defer h.connLimiter.release(ip)
+
+	h.handleConnection(ws, principal)
+}
+
+func (h *LivePricesHandler) handleConnection(conn ports.WebSocketConn, principal *domain.Principal) {
 	h.mu.Lock()
-	h.clients[conn] = true
+	h.clients[conn] = principal
 	h.mu.Unlock()
 
-	go h.manageConnection(ctx, conn)
-}
+	defer h.cleanupConnection(conn)
+
+	h.logger.Infof("New client connected: %v", conn.RemoteAddr())
+	h.priceService.AddClient(conn)
 
-func (h *LivePricesHandler) manageConnection(ctx *gin.Context, conn ports.WebSocketConn) {
-	defer func() {
-		h.cleanupConnection(conn)
-	}()
+	var bucket *tokenBucket
+	if h.messageRate > 0 {
+		bucket = newTokenBucket(h.messageRate, h.messageBurst, time.Now())
+	}
 
 	conn.SetReadLimit(maxMessageSize)
+
+	// Every pong, like every message, proves the peer is alive and pushes the
+	// read deadline out. A half-open connection stops answering and its read
+	// fails once the deadline passes.
+	h.extendReadDeadline(conn)
 	conn.SetPongHandler(func(string) error {
-		conn.SetReadDeadline(time.Now().Add(pongWait * time.Second))
+		h.extendReadDeadline(conn)
 		return nil
 	})
-
-	h.logger.Info("New client connected", zap.String("remote", conn.RemoteAddr().String()))
-	h.priceService.AddClient(conn)
+	stopPings := make(chan struct{})
+	defer close(stopPings)
+	go h.pingLoop(conn, stopPings)
 
 	for {
 		_, message, err := conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				h.logger.Error("Unexpected WebSocket closure", zap.Error(err))
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				reapedConnections.Add(1)
+				h.logger.Infof("Reaping client %v: no pong within %v", conn.RemoteAddr(), h.pongWait)
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				h.logger.Errorf("Unexpected WebSocket closure: %v", err)
 			}
 			break
 		}
+		h.extendReadDeadline(conn)
 
-		if err := h.handleClientMessage(conn, message); err != nil {
-			h.logger.Error("Message handling failed", zap.Error(err))
+		if bucket != nil && !bucket.allow(time.Now()) {
+			h.logger.Infof("Client %v exceeded the message rate limit, closing", conn.RemoteAddr())
+			h.closeWithCode(conn, websocket.ClosePolicyViolation, "rate limit exceeded")
 			break
 		}
+
+		h.handleClientMessage(conn, message)
+	}
+}
+
+func (h *LivePricesHandler) extendReadDeadline(conn ports.WebSocketConn) {
+	if err := conn.SetReadDeadline(time.Now().Add(h.pongWait)); err != nil {
+		h.logger.Errorf("Error setting read deadline for %v: %v", conn.RemoteAddr(), err)
+	}
+}
+
+// pingLoop pings conn every pingPeriod until stop is closed. Pings are control
+// frames, so they go out alongside the notifier's writer.
+func (h *LivePricesHandler) pingLoop(conn ports.WebSocketConn, stop <-chan struct{}) {
+	ticker := time.NewTicker(h.pingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				h.logger.Debugf("Error pinging %v: %v", conn.RemoteAddr(), err)
+				return
+			}
+		}
 	}
 }
 
-func (h *LivePricesHandler) handleClientMessage(conn ports.WebSocketConn, message []byte) error {
+// closeWithCode sends a close frame so the client learns why it is being
+// disconnected. The caller still closes the connection.
+func (h *LivePricesHandler) closeWithCode(conn ports.WebSocketConn, code int, reason string) {
+	msg := websocket.FormatCloseMessage(code, reason)
+	if err := conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait)); err != nil {
+		h.logger.Errorf("Error sending close frame to %v: %v", conn.RemoteAddr(), err)
+	}
+}
+
+// handleClientMessage applies a client request and replies with an ack or an
+// error envelope carrying the request ID.
+func (h *LivePricesHandler) handleClientMessage(conn ports.WebSocketConn, message []byte) {
 	var subMsg domain.SubscriptionMessage
 	if err := json.Unmarshal(message, &subMsg); err != nil {
-		h.sendError(conn, "Invalid message format")
-		return fmt.Errorf("invalid message format: %w", err)
+		h.sendError(conn, "", domain.ErrCodeInvalidMessage, "Invalid message format.")
+		return
+	}
+
+	switch subMsg.Action {
+	case domain.AlertCreate, domain.AlertDelete, domain.AlertList:
+		h.handleAlertRequest(conn, subMsg)
+		return
+	}
+
+	// Unsubscribing is always allowed, so a client can clean up after a
+	// symbol is removed.
+	if subMsg.Action == domain.Subscribe {
+		if !domain.IsSupportedStock(string(subMsg.Stock)) {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeUnsupportedStock, "Unsupported stock symbol")
+			return
+		}
+		if !h.entitled(conn, subMsg) {
+			return
+		}
 	}
 
-	if !domain.IsSupportedStock(string(subMsg.Stock)) {
-		h.sendError(conn, "Unsupported stock symbol")
-		return fmt.Errorf("unsupported stock: %s", subMsg.Stock)
+	switch subMsg.Channel {
+	case "", domain.ChannelTicker:
+		h.handleTickerRequest(conn, subMsg)
+	case domain.ChannelCandles:
+		h.handleCandleRequest(conn, subMsg)
+	default:
+		h.sendError(conn, subMsg.ID, domain.ErrCodeUnknownChannel, "Unknown channel")
 	}
+}
 
+func (h *LivePricesHandler) handleTickerRequest(conn ports.WebSocketConn, subMsg domain.SubscriptionMessage) {
 	switch subMsg.Action {
 	case domain.Subscribe:
-		return h.priceService.Subscribe(conn, subMsg.Stock)
+		fields, err := domain.ParseFields(subMsg.Fields)
+		if err != nil {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeInvalidFields, err.Error())
+			return
+		}
+		if subMsg.MaxRateMs < 0 {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeInvalidMaxRate, "max_rate_ms must not be negative")
+			return
+		}
+		if subMsg.ResumeFrom < 0 {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeInvalidResume, "resume_from must not be negative")
+			return
+		}
+		opts := domain.SubscriptionOptions{
+			Fields:     fields,
+			MaxRate:    time.Duration(subMsg.MaxRateMs) * time.Millisecond,
+			ResumeFrom: subMsg.ResumeFrom,
+			Ack:        newAck(subMsg),
+		}
+		if err := h.priceService.Subscribe(conn, subMsg.Stock, opts); err != nil {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeSubscribeFailed, err.Error())
+		}
+		return
 	case domain.Unsubscribe:
-		return h.priceService.Unsubscribe(conn, subMsg.Stock)
+		if err := h.priceService.Unsubscribe(conn, subMsg.Stock); err != nil {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeUnsubscribeFailed, err.Error())
+			return
+		}
 	default:
-		h.sendError(conn, "Invalid action")
-		return fmt.Errorf("unknown action: %s", subMsg.Action)
+		h.sendError(conn, subMsg.ID, domain.ErrCodeUnknownAction, "Unknown action")
+		return
 	}
+
+	h.sendAck(conn, subMsg)
+}
+
+func (h *LivePricesHandler) handleCandleRequest(conn ports.WebSocketConn, subMsg domain.SubscriptionMessage) {
+	interval, ok := domain.ParseCandleInterval(string(subMsg.Interval))
+	if !ok {
+		h.sendError(conn, subMsg.ID, domain.ErrCodeInvalidInterval, "Unsupported candle interval")
+		return
+	}
+
+	switch subMsg.Action {
+	case domain.Subscribe:
+		if err := h.priceService.SubscribeCandles(conn, subMsg.Stock, interval, newAck(subMsg)); err != nil {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeSubscribeFailed, err.Error())
+		}
+		return
+	case domain.Unsubscribe:
+		if err := h.priceService.UnsubscribeCandles(conn, subMsg.Stock, interval); err != nil {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeUnsubscribeFailed, err.Error())
+			return
+		}
+	default:
+		h.sendError(conn, subMsg.ID, domain.ErrCodeUnknownAction, "Unknown action")
+		return
+	}
+
+	h.sendAck(conn, subMsg)
+}
+
+func (h *LivePricesHandler) handleAlertRequest(conn ports.WebSocketConn, subMsg domain.SubscriptionMessage) {
+	switch subMsg.Action {
+	case domain.AlertCreate:
+		if !domain.IsSupportedStock(string(subMsg.Stock)) {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeUnsupportedStock, "Unsupported stock symbol")
+			return
+		}
+		if !h.entitled(conn, subMsg) {
+			return
+		}
+		if subMsg.Alert == nil {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeInvalidAlert, "alert is required")
+			return
+		}
+		alert, err := h.priceService.CreateAlert(conn, subMsg.Stock, *subMsg.Alert)
+		if err != nil {
+			code := domain.ErrCodeInvalidAlert
+			if errors.Is(err, domain.ErrTooManyAlerts) {
+				code = domain.ErrCodeTooManyAlerts
+			}
+			h.sendError(conn, subMsg.ID, code, err.Error())
+			return
+		}
+		subMsg.AlertID = alert.ID
+	case domain.AlertDelete:
+		if subMsg.AlertID == "" {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeInvalidAlert, "alert_id is required")
+			return
+		}
+		if err := h.priceService.DeleteAlert(conn, subMsg.AlertID); err != nil {
+			h.sendError(conn, subMsg.ID, domain.ErrCodeUnknownAlert, err.Error())
+			return
+		}
+	case domain.AlertList:
+		list := domain.AlertListMessage{
+			Type:   domain.AlertListType,
+			ID:     subMsg.ID,
+			Alerts: h.priceService.Alerts(conn),
+		}
+		if err := h.priceService.Send(conn, list); err != nil {
+			h.logger.Errorf("Failed to send alert list to %v: %v", conn.RemoteAddr(), err)
+		}
+		return
+	}
+
+	h.sendAck(conn, subMsg)
+}
+
+// entitled reports whether the connection's principal may stream subMsg.Stock,
+// replying with an error when it may not.
+func (h *LivePricesHandler) entitled(conn ports.WebSocketConn, subMsg domain.SubscriptionMessage) bool {
+	h.mu.Lock()
+	principal := h.clients[conn]
+	h.mu.Unlock()
+
+	if principal != nil && principal.Entitled(subMsg.Stock) {
+		return true
+	}
+	h.sendError(conn, subMsg.ID, domain.ErrCodeNotEntitled, "Not entitled to "+string(subMsg.Stock))
+	return false
 }
 
 func (h *LivePricesHandler) cleanupConnection(conn ports.WebSocketConn) {
@@ -121,27 +416,42 @@ func (h *LivePricesHandler) cleanupConnection(conn ports.WebSocketConn) {
 	if _, ok := h.clients[conn]; ok {
 		h.priceService.RemoveClient(conn)
 		delete(h.clients, conn)
-		conn.Close()
-		h.logger.Info("Client disconnected", zap.String("remote", conn.RemoteAddr().String()))
+		if err := conn.Close(); err != nil {
+			h.logger.Errorf("Error closing WebSocket: %v", err)
+		}
+		h.logger.Infof("Client disconnected: %v", conn.RemoteAddr())
+	}
+}
+
+// newAck builds the reply to subMsg. Subscriptions hand it to the price
+// service so it is queued before the first snapshot or replayed event.
+func newAck(subMsg domain.SubscriptionMessage) *domain.AckMessage {
+	return &domain.AckMessage{
+		Type:     "ack",
+		ID:       subMsg.ID,
+		Action:   subMsg.Action,
+		Stock:    subMsg.Stock,
+		Channel:  subMsg.Channel,
+		Interval: subMsg.Interval,
+		AlertID:  subMsg.AlertID,
 	}
 }
 
-func (h *LivePricesHandler) sendError(conn ports.WebSocketConn, errorMessage string) {
+func (h *LivePricesHandler) sendAck(conn ports.WebSocketConn, subMsg domain.SubscriptionMessage) {
+	if err := h.priceService.Send(conn, *newAck(subMsg)); err != nil {
+		h.logger.Errorf("Failed to send ack to %v: %v", conn.RemoteAddr(), err)
+	}
+}
+
+func (h *LivePricesHandler) sendError(conn ports.WebSocketConn, id string, code domain.ErrorCode, errorMessage string) {
 	errMsg := domain.ErrorMessage{
 		Type:    "error",
+		ID:      id,
+		Code:    code,
 		Message: errorMessage,
 	}
 
-	message, err := json.Marshal(errMsg)
-	if err != nil {
-		h.logger.Error("Failed to marshal error message", zap.Error(err))
-		return
-	}
-
-	conn.SetWriteDeadline(time.Now().Add(writeWait * time.Second))
-	if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
-		h.logger.Error("Failed to send error message", 
-			zap.String("remote", conn.RemoteAddr().String()),
-			zap.Error(err))
+	if err := h.priceService.Send(conn, errMsg); err != nil {
+		h.logger.Errorf("Failed to send error message to %v: %v", conn.RemoteAddr(), err)
 	}
 }
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
@@ -41,6 +43,9 @@ func setup(t *testing.T) *testDependencies {
 	mockPriceService := mocks.NewMockPriceService(ctrl)
 	mockLogger := &mocks.StubLogger{}
 	mockConn := mocks.NewMockWebSocketConn(ctrl)
+	mockConn.EXPECT().SetReadDeadline(gomock.Any()).Return(nil).AnyTimes()
+	mockConn.EXPECT().SetPongHandler(gomock.Any()).AnyTimes()
+	mockConn.EXPECT().SetReadLimit(int64(maxMessageSize)).AnyTimes()
 	stubbedAddr := &stubAddr{address: "127.0.0.1:12345"}
 	handler := NewLivePricesHandler(mockPriceService, mockLogger)
 	return &testDependencies{
@@ -60,6 +65,7 @@ func TestHandleConnection_ValidSubscription(t *testing.T) {
 	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
 
 	subMsg := domain.SubscriptionMessage{
+		ID:     "req-1",
 		Action: domain.Subscribe,
 		Stock:  domain.Stock("BTC-USD"),
 	}
@@ -77,11 +83,62 @@ func TestHandleConnection_ValidSubscription(t *testing.T) {
 	domain.IsSupportedStock = func(stock string) bool { return true }
 	defer func() { domain.IsSupportedStock = originalIsSupportedStock }()
 
-	deps.mockPriceService.EXPECT().Subscribe(deps.mockConn, subMsg.Stock).Return(nil)
+	// The ack travels with the subscription so it is queued before the
+	// snapshot.
+	deps.mockPriceService.EXPECT().Subscribe(deps.mockConn, subMsg.Stock, domain.SubscriptionOptions{
+		Ack: &domain.AckMessage{
+			Type:   "ack",
+			ID:     "req-1",
+			Action: domain.Subscribe,
+			Stock:  subMsg.Stock,
+		},
+	}).Return(nil)
 	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
 	deps.mockConn.EXPECT().Close().Return(nil)
 
-	deps.handler.handleConnection(nil, deps.mockConn)
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_UnsubscribeAfterSymbolRemoved(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	const stock domain.Stock = "DOGE-USD"
+	domain.Symbols.Register(stock)
+	t.Cleanup(func() { domain.Symbols.Unregister(stock) })
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	subscribe, err := json.Marshal(domain.SubscriptionMessage{ID: "req-1", Action: domain.Subscribe, Stock: stock})
+	assert.NoError(t, err)
+	unsubscribe, err := json.Marshal(domain.SubscriptionMessage{ID: "req-2", Action: domain.Unsubscribe, Stock: stock})
+	assert.NoError(t, err)
+
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, subscribe, nil),
+		deps.mockConn.EXPECT().ReadMessage().DoAndReturn(func() (int, []byte, error) {
+			// An operator removes the symbol while the client is subscribed.
+			domain.Symbols.Unregister(stock)
+			return websocket.TextMessage, unsubscribe, nil
+		}),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
+
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	gomock.InOrder(
+		deps.mockPriceService.EXPECT().Subscribe(deps.mockConn, stock, gomock.Any()).Return(nil),
+		deps.mockPriceService.EXPECT().Unsubscribe(deps.mockConn, stock).Return(nil),
+		deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.AckMessage{
+			Type:   "ack",
+			ID:     "req-2",
+			Action: domain.Unsubscribe,
+			Stock:  stock,
+		}).Return(nil),
+	)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
 }
 
 func TestHandleConnection_InvalidMessageFormat(t *testing.T) {
@@ -101,16 +158,14 @@ func TestHandleConnection_InvalidMessageFormat(t *testing.T) {
 
 	expectedErrorMessage := domain.ErrorMessage{
 		Type:    "error",
+		Code:    domain.ErrCodeInvalidMessage,
 		Message: "Invalid message format.",
 	}
-	expectedErrorBytes, err := json.Marshal(expectedErrorMessage)
-	assert.NoError(t, err)
-
-	deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, expectedErrorBytes).Return(nil)
+	deps.mockPriceService.EXPECT().Send(deps.mockConn, expectedErrorMessage).Return(nil)
 	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
 	deps.mockConn.EXPECT().Close().Return(nil)
 
-	deps.handler.handleConnection(nil, deps.mockConn)
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
 }
 
 func TestHandleConnection_UnsupportedStockSymbol(t *testing.T) {
@@ -139,16 +194,14 @@ func TestHandleConnection_UnsupportedStockSymbol(t *testing.T) {
 
 	expectedErrorMessage := domain.ErrorMessage{
 		Type:    "error",
+		Code:    domain.ErrCodeUnsupportedStock,
 		Message: "Unsupported stock symbol",
 	}
-	expectedErrorBytes, err := json.Marshal(expectedErrorMessage)
-	assert.NoError(t, err)
-
-	deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, expectedErrorBytes).Return(nil)
+	deps.mockPriceService.EXPECT().Send(deps.mockConn, expectedErrorMessage).Return(nil)
 	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
 	deps.mockConn.EXPECT().Close().Return(nil)
 
-	deps.handler.handleConnection(nil, deps.mockConn)
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
 }
 
 func TestHandleConnection_UnknownAction(t *testing.T) {
@@ -177,16 +230,14 @@ func TestHandleConnection_UnknownAction(t *testing.T) {
 
 	expectedErrorMessage := domain.ErrorMessage{
 		Type:    "error",
+		Code:    domain.ErrCodeUnknownAction,
 		Message: "Unknown action",
 	}
-	expectedErrorBytes, err := json.Marshal(expectedErrorMessage)
-	assert.NoError(t, err)
-
-	deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, expectedErrorBytes).Return(nil)
+	deps.mockPriceService.EXPECT().Send(deps.mockConn, expectedErrorMessage).Return(nil)
 	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
 	deps.mockConn.EXPECT().Close().Return(nil)
 
-	deps.handler.handleConnection(nil, deps.mockConn)
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
 }
 
 func TestHandleConnection_ReadMessageError(t *testing.T) {
@@ -200,27 +251,27 @@ func TestHandleConnection_ReadMessageError(t *testing.T) {
 	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
 	deps.mockConn.EXPECT().Close().Return(nil)
 
-	deps.handler.handleConnection(nil, deps.mockConn)
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
 }
 
 func TestHandleConnection_SubscribeError(t *testing.T) {
 	deps := setup(t)
 	defer deps.ctrl.Finish()
 
-	gin.SetMode(gin.TestMode)
-	w := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(w)
-
 	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
 
 	subMsg := domain.SubscriptionMessage{
+		ID:     "req-2",
 		Action: domain.Subscribe,
 		Stock:  domain.Stock("BTC-USD"),
 	}
 	messageBytes, err := json.Marshal(subMsg)
 	assert.NoError(t, err)
 
-	deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil)
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
 	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
 
 	originalIsSupportedStock := domain.IsSupportedStock
@@ -228,33 +279,37 @@ func TestHandleConnection_SubscribeError(t *testing.T) {
 	defer func() { domain.IsSupportedStock = originalIsSupportedStock }()
 
 	subscribeErr := fmt.Errorf("subscribe error")
-	deps.mockPriceService.EXPECT().Subscribe(deps.mockConn, subMsg.Stock).Return(subscribeErr)
+	deps.mockPriceService.EXPECT().Subscribe(deps.mockConn, subMsg.Stock, gomock.Any()).Return(subscribeErr)
+	deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.ErrorMessage{
+		Type:    "error",
+		ID:      "req-2",
+		Code:    domain.ErrCodeSubscribeFailed,
+		Message: "subscribe error",
+	}).Return(nil)
 	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
 	deps.mockConn.EXPECT().Close().Return(nil)
 
-	deps.handler.handleConnection(ctx, deps.mockConn)
-
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
 }
 
 func TestHandleConnection_UnsubscribeError(t *testing.T) {
 	deps := setup(t)
 	defer deps.ctrl.Finish()
 
-	gin.SetMode(gin.TestMode)
-	w := httptest.NewRecorder()
-	ctx, _ := gin.CreateTestContext(w)
-
 	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
 
 	subMsg := domain.SubscriptionMessage{
+		ID:     "req-3",
 		Action: domain.Unsubscribe,
 		Stock:  domain.Stock("BTC-USD"),
 	}
 	messageBytes, err := json.Marshal(subMsg)
 	assert.NoError(t, err)
 
-	deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil)
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
 	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
 
 	originalIsSupportedStock := domain.IsSupportedStock
@@ -263,10 +318,587 @@ func TestHandleConnection_UnsubscribeError(t *testing.T) {
 
 	unsubscribeErr := fmt.Errorf("unsubscribe error")
 	deps.mockPriceService.EXPECT().Unsubscribe(deps.mockConn, subMsg.Stock).Return(unsubscribeErr)
+	deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.ErrorMessage{
+		Type:    "error",
+		ID:      "req-3",
+		Code:    domain.ErrCodeUnsubscribeFailed,
+		Message: "unsubscribe error",
+	}).Return(nil)
 	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
 	deps.mockConn.EXPECT().Close().Return(nil)
 
-	deps.handler.handleConnection(ctx, deps.mockConn)
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_SubscribeWithFields(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	subMsg := domain.SubscriptionMessage{
+		Action: domain.Subscribe,
+		Stock:  domain.StockBitcoin,
+		Fields: []string{"price", "best_bid", "best_ask"},
+	}
+	messageBytes, err := json.Marshal(subMsg)
+	assert.NoError(t, err)
+
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	deps.mockPriceService.EXPECT().Subscribe(deps.mockConn, subMsg.Stock, domain.SubscriptionOptions{
+		Fields: []string{"ProductID", "Price", "BestBid", "BestAsk"},
+		Ack:    newAck(subMsg),
+	}).Return(nil)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_SubscribeWithUnknownField(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	subMsg := domain.SubscriptionMessage{
+		ID:     "req-4",
+		Action: domain.Subscribe,
+		Stock:  domain.StockBitcoin,
+		Fields: []string{"colour"},
+	}
+	messageBytes, err := json.Marshal(subMsg)
+	assert.NoError(t, err)
+
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.ErrorMessage{
+		Type:    "error",
+		ID:      "req-4",
+		Code:    domain.ErrCodeInvalidFields,
+		Message: "unknown field: colour",
+	}).Return(nil)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_SubscribeWithMaxRate(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	messageBytes := []byte(`{"action":"subscribe","stock":"BTC-USD","max_rate_ms":1000}`)
+
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	deps.mockPriceService.EXPECT().Subscribe(deps.mockConn, domain.StockBitcoin, domain.SubscriptionOptions{
+		MaxRate: time.Second,
+		Ack:     &domain.AckMessage{Type: "ack", Action: domain.Subscribe, Stock: domain.StockBitcoin},
+	}).Return(nil)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_SubscribeWithNegativeMaxRate(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	messageBytes := []byte(`{"id":"req-5","action":"subscribe","stock":"BTC-USD","max_rate_ms":-1}`)
+
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.ErrorMessage{
+		Type:    "error",
+		ID:      "req-5",
+		Code:    domain.ErrCodeInvalidMaxRate,
+		Message: "max_rate_ms must not be negative",
+	}).Return(nil)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_SubscribeWithResumeFrom(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	messageBytes := []byte(`{"action":"subscribe","stock":"BTC-USD","resume_from":42}`)
+
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	deps.mockPriceService.EXPECT().Subscribe(deps.mockConn, domain.StockBitcoin, domain.SubscriptionOptions{
+		ResumeFrom: 42,
+		Ack:        &domain.AckMessage{Type: "ack", Action: domain.Subscribe, Stock: domain.StockBitcoin},
+	}).Return(nil)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_SubscribeWithNegativeResumeFrom(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	messageBytes := []byte(`{"id":"req-6","action":"subscribe","stock":"BTC-USD","resume_from":-1}`)
+
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.ErrorMessage{
+		Type:    "error",
+		ID:      "req-6",
+		Code:    domain.ErrCodeInvalidResume,
+		Message: "resume_from must not be negative",
+	}).Return(nil)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_CandleSubscription(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	subMsg := domain.SubscriptionMessage{
+		ID:       "req-7",
+		Action:   domain.Subscribe,
+		Stock:    domain.StockBitcoin,
+		Channel:  domain.ChannelCandles,
+		Interval: domain.Interval5m,
+	}
+	messageBytes, err := json.Marshal(subMsg)
+	assert.NoError(t, err)
+
+	gomock.InOrder(
+		deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+		deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+	)
+
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	deps.mockPriceService.EXPECT().SubscribeCandles(deps.mockConn, domain.StockBitcoin, domain.Interval5m, &domain.AckMessage{
+		Type:     "ack",
+		ID:       "req-7",
+		Action:   domain.Subscribe,
+		Stock:    domain.StockBitcoin,
+		Channel:  domain.ChannelCandles,
+		Interval: domain.Interval5m,
+	}).Return(nil)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_InvalidCandleRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		message domain.SubscriptionMessage
+		code    domain.ErrorCode
+	}{
+		{
+			name:    "unsupported interval",
+			message: domain.SubscriptionMessage{ID: "req-8", Action: domain.Subscribe, Stock: domain.StockBitcoin, Channel: domain.ChannelCandles, Interval: "2m"},
+			code:    domain.ErrCodeInvalidInterval,
+		},
+		{
+			name:    "unknown channel",
+			message: domain.SubscriptionMessage{ID: "req-8", Action: domain.Subscribe, Stock: domain.StockBitcoin, Channel: "trades"},
+			code:    domain.ErrCodeUnknownChannel,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := setup(t)
+			defer deps.ctrl.Finish()
+
+			deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+			messageBytes, err := json.Marshal(tt.message)
+			assert.NoError(t, err)
+
+			gomock.InOrder(
+				deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+				deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+			)
+
+			deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+			deps.mockPriceService.EXPECT().Send(deps.mockConn, gomock.Cond(func(x any) bool {
+				msg, ok := x.(domain.ErrorMessage)
+				return ok && msg.ID == "req-8" && msg.Code == tt.code
+			})).Return(nil)
+			deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+			deps.mockConn.EXPECT().Close().Return(nil)
+
+			deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+		})
+	}
+}
+
+func TestHandleConnection_AlertLifecycle(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	spec := domain.AlertSpec{Condition: domain.CrossesAbove, Threshold: 70000}
+	alert := domain.Alert{ID: "alert-1", Stock: domain.StockBitcoin, AlertSpec: spec}
+	messages := []domain.SubscriptionMessage{
+		{ID: "req-1", Action: domain.AlertCreate, Stock: domain.StockBitcoin, Alert: &spec},
+		{ID: "req-2", Action: domain.AlertList},
+		{ID: "req-3", Action: domain.AlertDelete, AlertID: "alert-1"},
+	}
+
+	var reads []any
+	for _, msg := range messages {
+		messageBytes, err := json.Marshal(msg)
+		assert.NoError(t, err)
+		reads = append(reads, deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil))
+	}
+	reads = append(reads, deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")))
+	gomock.InOrder(reads...)
+
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	gomock.InOrder(
+		deps.mockPriceService.EXPECT().CreateAlert(deps.mockConn, domain.StockBitcoin, spec).Return(alert, nil),
+		deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.AckMessage{
+			Type: "ack", ID: "req-1", Action: domain.AlertCreate, Stock: domain.StockBitcoin, AlertID: "alert-1",
+		}).Return(nil),
+		deps.mockPriceService.EXPECT().Alerts(deps.mockConn).Return([]domain.Alert{alert}),
+		deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.AlertListMessage{
+			Type: domain.AlertListType, ID: "req-2", Alerts: []domain.Alert{alert},
+		}).Return(nil),
+		deps.mockPriceService.EXPECT().DeleteAlert(deps.mockConn, "alert-1").Return(nil),
+		deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.AckMessage{
+			Type: "ack", ID: "req-3", Action: domain.AlertDelete, AlertID: "alert-1",
+		}).Return(nil),
+	)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleConnection_InvalidAlertRequests(t *testing.T) {
+	spec := domain.AlertSpec{Condition: domain.CrossesAbove, Threshold: 70000}
+	tests := []struct {
+		name    string
+		message domain.SubscriptionMessage
+		expect  func(deps *testDependencies)
+		code    domain.ErrorCode
+	}{
+		{
+			name:    "missing rule",
+			message: domain.SubscriptionMessage{ID: "req-9", Action: domain.AlertCreate, Stock: domain.StockBitcoin},
+			code:    domain.ErrCodeInvalidAlert,
+		},
+		{
+			name:    "rejected rule",
+			message: domain.SubscriptionMessage{ID: "req-9", Action: domain.AlertCreate, Stock: domain.StockBitcoin, Alert: &spec},
+			expect: func(deps *testDependencies) {
+				deps.mockPriceService.EXPECT().CreateAlert(deps.mockConn, domain.StockBitcoin, spec).
+					Return(domain.Alert{}, fmt.Errorf("threshold must be positive"))
+			},
+			code: domain.ErrCodeInvalidAlert,
+		},
+		{
+			name:    "too many alerts",
+			message: domain.SubscriptionMessage{ID: "req-9", Action: domain.AlertCreate, Stock: domain.StockBitcoin, Alert: &spec},
+			expect: func(deps *testDependencies) {
+				deps.mockPriceService.EXPECT().CreateAlert(deps.mockConn, domain.StockBitcoin, spec).
+					Return(domain.Alert{}, fmt.Errorf("%w: at most 50 per connection", domain.ErrTooManyAlerts))
+			},
+			code: domain.ErrCodeTooManyAlerts,
+		},
+		{
+			name:    "unsupported stock",
+			message: domain.SubscriptionMessage{ID: "req-9", Action: domain.AlertCreate, Stock: "DOGE-USD", Alert: &spec},
+			code:    domain.ErrCodeUnsupportedStock,
+		},
+		{
+			name:    "unknown alert",
+			message: domain.SubscriptionMessage{ID: "req-9", Action: domain.AlertDelete, AlertID: "alert-42"},
+			expect: func(deps *testDependencies) {
+				deps.mockPriceService.EXPECT().DeleteAlert(deps.mockConn, "alert-42").Return(domain.ErrUnknownAlert)
+			},
+			code: domain.ErrCodeUnknownAlert,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := setup(t)
+			defer deps.ctrl.Finish()
+
+			deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+			messageBytes, err := json.Marshal(tt.message)
+			assert.NoError(t, err)
+
+			gomock.InOrder(
+				deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil),
+				deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")),
+			)
+
+			deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+			if tt.expect != nil {
+				tt.expect(deps)
+			}
+			deps.mockPriceService.EXPECT().Send(deps.mockConn, gomock.Cond(func(x any) bool {
+				msg, ok := x.(domain.ErrorMessage)
+				return ok && msg.ID == "req-9" && msg.Code == tt.code
+			})).Return(nil)
+			deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+			deps.mockConn.EXPECT().Close().Return(nil)
+
+			deps.handler.handleConnection(deps.mockConn, domain.Anonymous)
+		})
+	}
+}
+
+func TestHandleConnection_SubscribeNotEntitled(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	originalIsSupportedStock := domain.IsSupportedStock
+	domain.IsSupportedStock = func(stock string) bool { return true }
+	defer func() { domain.IsSupportedStock = originalIsSupportedStock }()
+
+	spec := domain.AlertSpec{Condition: domain.CrossesAbove, Threshold: 4000}
+	messages := []domain.SubscriptionMessage{
+		{ID: "req-1", Action: domain.Subscribe, Stock: "ETH-USD"},
+		{ID: "req-2", Action: domain.Subscribe, Stock: "ETH-USD", Channel: domain.ChannelCandles, Interval: domain.Interval1m},
+		{ID: "req-3", Action: domain.AlertCreate, Stock: "ETH-USD", Alert: &spec},
+	}
+	var reads []any
+	for _, msg := range messages {
+		messageBytes, err := json.Marshal(msg)
+		assert.NoError(t, err)
+		reads = append(reads, deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, messageBytes, nil))
+	}
+	reads = append(reads, deps.mockConn.EXPECT().ReadMessage().Return(0, nil, fmt.Errorf("EOF")))
+	gomock.InOrder(reads...)
+
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	for _, msg := range messages {
+		deps.mockPriceService.EXPECT().Send(deps.mockConn, domain.ErrorMessage{
+			Type:    "error",
+			ID:      msg.ID,
+			Code:    domain.ErrCodeNotEntitled,
+			Message: "Not entitled to ETH-USD",
+		}).Return(nil)
+	}
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	principal := &domain.Principal{Subject: "alice", Stocks: []domain.Stock{domain.StockBitcoin}}
+	deps.handler.handleConnection(deps.mockConn, principal)
+}
+
+func TestHandleWebSocket_RejectsUnauthenticated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authenticator := mocks.NewMockAuthenticator(ctrl)
+	authenticator.EXPECT().Authenticate(domain.Credentials{APIKey: "wrong"}).Return(nil, domain.ErrInvalidCredentials)
+	handler := NewLivePricesHandler(mocks.NewMockPriceService(ctrl), &mocks.StubLogger{}, WithAuthenticator(authenticator))
+
+	router := gin.New()
+	router.GET("/ws/livepricesfeed", handler.HandleWebSocket)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ws/livepricesfeed", nil)
+	req.Header.Set("X-API-Key", "wrong")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeUnauthorized))
+}
+
+func TestHandleConnection_ClosesClientsOverMessageRate(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+	handler := NewLivePricesHandler(deps.mockPriceService, deps.mockLogger, WithMessageRateLimit(0.001, 2))
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+
+	listMsg, err := json.Marshal(domain.SubscriptionMessage{Action: domain.AlertList})
+	assert.NoError(t, err)
+	deps.mockConn.EXPECT().ReadMessage().Return(websocket.TextMessage, listMsg, nil).Times(3)
+
+	deps.mockPriceService.EXPECT().AddClient(deps.mockConn)
+	deps.mockPriceService.EXPECT().Alerts(deps.mockConn).Return(nil).Times(2)
+	deps.mockPriceService.EXPECT().Send(deps.mockConn, gomock.Any()).Return(nil).Times(2)
+	deps.mockConn.EXPECT().WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "rate limit exceeded"),
+		gomock.Any(),
+	).Return(nil)
+	deps.mockPriceService.EXPECT().RemoveClient(deps.mockConn)
+	deps.mockConn.EXPECT().Close().Return(nil)
+
+	handler.handleConnection(deps.mockConn, domain.Anonymous)
+}
+
+func TestHandleWebSocket_CapsConnectionsPerIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	priceService := mocks.NewMockPriceService(ctrl)
+	priceService.EXPECT().AddClient(gomock.Any()).AnyTimes()
+	priceService.EXPECT().RemoveClient(gomock.Any()).AnyTimes()
+	handler := NewLivePricesHandler(priceService, &mocks.StubLogger{}, WithMaxConnectionsPerIP(1))
+
+	router := gin.New()
+	assert.NoError(t, router.SetTrustedProxies(nil))
+	router.GET("/ws/livepricesfeed", handler.HandleWebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	url := "ws" + server.URL[len("http"):] + "/ws/livepricesfeed"
+	first, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer first.Close()
+
+	// A spoofed X-Forwarded-For must not dodge the cap when no proxy is trusted.
+	second, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-Forwarded-For": {"198.51.100.1"}})
+	assert.NoError(t, err)
+	defer second.Close()
+
+	_ = second.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err = second.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater), "got %v", err)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestHandleConnection_PingsAndReapsSilentClients(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	priceService := mocks.NewMockPriceService(ctrl)
+	conn := mocks.NewMockWebSocketConn(ctrl)
+	handler := NewLivePricesHandler(priceService, &mocks.StubLogger{}, WithHeartbeat(5*time.Millisecond, 50*time.Millisecond))
+
+	var mu sync.Mutex
+	var deadline time.Time
+	pinged := make(chan struct{}, 1)
+	conn.EXPECT().RemoteAddr().Return(&stubAddr{address: "127.0.0.1:12345"}).AnyTimes()
+	conn.EXPECT().SetReadDeadline(gomock.Any()).DoAndReturn(func(t time.Time) error {
+		mu.Lock()
+		defer mu.Unlock()
+		deadline = t
+		return nil
+	}).AnyTimes()
+	conn.EXPECT().SetPongHandler(gomock.Any())
+	conn.EXPECT().SetReadLimit(int64(maxMessageSize))
+	conn.EXPECT().WriteControl(websocket.PingMessage, gomock.Any(), gomock.Any()).DoAndReturn(func(int, []byte, time.Time) error {
+		select {
+		case pinged <- struct{}{}:
+		default:
+		}
+		return nil
+	}).MinTimes(1)
+	// The peer never answers: the read blocks until the deadline passes.
+	conn.EXPECT().ReadMessage().DoAndReturn(func() (int, []byte, error) {
+		<-pinged
+		mu.Lock()
+		wait := time.Until(deadline)
+		mu.Unlock()
+		time.Sleep(wait)
+		return 0, nil, timeoutError{}
+	})
+	priceService.EXPECT().AddClient(conn)
+	priceService.EXPECT().RemoveClient(conn)
+	conn.EXPECT().Close().Return(nil)
+
+	before := reapedConnections.Value()
+	handler.handleConnection(conn, domain.Anonymous)
+
+	assert.Equal(t, before+1, reapedConnections.Value())
+}
+
+func TestHandleConnection_PongExtendsReadDeadline(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	var pongHandler func(string) error
+	conn := mocks.NewMockWebSocketConn(deps.ctrl)
+	conn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+	conn.EXPECT().SetPongHandler(gomock.Any()).Do(func(h func(string) error) { pongHandler = h })
+	conn.EXPECT().SetReadLimit(int64(maxMessageSize))
+	gomock.InOrder(
+		conn.EXPECT().SetReadDeadline(gomock.Any()).Return(nil), // initial deadline
+		conn.EXPECT().ReadMessage().DoAndReturn(func() (int, []byte, error) {
+			assert.NoError(t, pongHandler(""))
+			return 0, nil, fmt.Errorf("EOF")
+		}),
+	)
+	conn.EXPECT().SetReadDeadline(gomock.Any()).Return(nil) // extended by the pong
+	deps.mockPriceService.EXPECT().AddClient(conn)
+	deps.mockPriceService.EXPECT().RemoveClient(conn)
+	conn.EXPECT().Close().Return(nil)
+
+	deps.handler.handleConnection(conn, domain.Anonymous)
+}
+
+func TestHandleConnection_LimitsMessageSize(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	conn := mocks.NewMockWebSocketConn(deps.ctrl)
+	conn.EXPECT().RemoteAddr().Return(deps.stubbedAddr).AnyTimes()
+	conn.EXPECT().SetPongHandler(gomock.Any())
+	conn.EXPECT().SetReadDeadline(gomock.Any()).Return(nil)
+	gomock.InOrder(
+		conn.EXPECT().SetReadLimit(int64(maxMessageSize)),
+		// gorilla fails the read once a frame exceeds the limit.
+		conn.EXPECT().ReadMessage().Return(0, nil, websocket.ErrReadLimit),
+	)
+	deps.mockPriceService.EXPECT().AddClient(conn)
+	deps.mockPriceService.EXPECT().RemoveClient(conn)
+	conn.EXPECT().Close().Return(nil)
 
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	deps.handler.handleConnection(conn, domain.Anonymous)
 }
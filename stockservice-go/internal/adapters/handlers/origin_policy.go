@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// OriginPolicy decides which browser origins may open a WebSocket. Origins are
+// matched on scheme, host and port.
+type OriginPolicy struct {
+	exact     map[string]struct{}
+	wildcards []wildcardOrigin
+	// allowLocalhost admits any scheme and port on localhost, for development.
+	allowLocalhost bool
+}
+
+// wildcardOrigin matches any subdomain of suffix, but not suffix itself.
+type wildcardOrigin struct {
+	scheme string
+	suffix string // e.g. ".example.com" or ".example.com:8443"
+}
+
+// NewOriginPolicy builds a policy from allowlist entries such as
+// "https://app.example.com" or "https://*.example.com". devMode additionally
+// allows localhost origins.
+func NewOriginPolicy(allowed []string, devMode bool) (*OriginPolicy, error) {
+	p := &OriginPolicy{
+		exact:          make(map[string]struct{}),
+		allowLocalhost: devMode,
+	}
+
+	for _, entry := range allowed {
+		u, err := url.Parse(strings.ToLower(strings.TrimSuffix(strings.TrimSpace(entry), "/")))
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			return nil, fmt.Errorf("invalid allowed origin %q: expected scheme://host[:port]", entry)
+		}
+
+		if rest, ok := strings.CutPrefix(u.Host, "*."); ok {
+			if rest == "" || strings.Contains(rest, "*") {
+				return nil, fmt.Errorf("invalid allowed origin %q: wildcard must be followed by a domain", entry)
+			}
+			p.wildcards = append(p.wildcards, wildcardOrigin{scheme: u.Scheme, suffix: "." + rest})
+			continue
+		}
+		if strings.Contains(u.Host, "*") {
+			return nil, fmt.Errorf("invalid allowed origin %q: only a leading \"*.\" wildcard is supported", entry)
+		}
+		p.exact[u.Scheme+"://"+u.Host] = struct{}{}
+	}
+	return p, nil
+}
+
+// Allowed reports whether a request may be upgraded. Requests without an
+// Origin header come from non-browser clients and are allowed, as are
+// same-origin requests.
+func (p *OriginPolicy) Allowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	if _, ok := p.exact[u.Scheme+"://"+u.Host]; ok {
+		return true
+	}
+	for _, wildcard := range p.wildcards {
+		if u.Scheme == wildcard.scheme && strings.HasSuffix(u.Host, wildcard.suffix) {
+			label := strings.TrimSuffix(u.Host, wildcard.suffix)
+			if label != "" && !strings.Contains(label, ":") {
+				return true
+			}
+		}
+	}
+	return p.allowLocalhost && isLocalhost(u.Hostname())
+}
+
+func isLocalhost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func upgradeRequest(origin string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "http://feed.example.net/ws/livepricesfeed", nil)
+	r.RemoteAddr = "203.0.113.7:51234"
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	return r
+}
+
+func TestOriginPolicy_Allowed(t *testing.T) {
+	policy, err := NewOriginPolicy([]string{"https://app.example.com", "https://*.example.org/", "http://*.staging.example.com:8080"}, false)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"", true},
+		{"http://feed.example.net", true}, // same origin
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"http://app.example.com", false},
+		{"https://app.example.com:8443", false},
+		{"https://evil.com", false},
+		{"https://app.example.com.evil.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evilexample.org", false},
+		{"https://a.example.org:444", false},
+		{"http://web.staging.example.com:8080", true},
+		{"http://web.staging.example.com", false},
+		{"http://localhost:5173", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, policy.Allowed(upgradeRequest(tt.origin)), tt.origin)
+	}
+}
+
+func TestOriginPolicy_DevModeAllowsLocalhost(t *testing.T) {
+	policy, err := NewOriginPolicy(nil, true)
+	assert.NoError(t, err)
+
+	for _, origin := range []string{"http://localhost:5173", "https://localhost", "http://127.0.0.1:3000", "http://[::1]:8080"} {
+		assert.True(t, policy.Allowed(upgradeRequest(origin)), origin)
+	}
+	assert.False(t, policy.Allowed(upgradeRequest("http://localhost.evil.com")))
+}
+
+func TestNewOriginPolicy_RejectsInvalidEntries(t *testing.T) {
+	for _, entry := range []string{"app.example.com", "https://app.example.com/path", "https://*", "https://app.*.com", "*"} {
+		_, err := NewOriginPolicy([]string{entry}, false)
+		assert.Error(t, err, entry)
+	}
+}
+
+func TestLivePricesHandler_CheckOriginLogsRejection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := mocks.NewMockLogger(ctrl)
+	policy, err := NewOriginPolicy([]string{"https://app.example.com"}, false)
+	assert.NoError(t, err)
+	handler := NewLivePricesHandler(mocks.NewMockPriceService(ctrl), logger, WithOriginPolicy(policy))
+
+	assert.True(t, handler.checkOrigin(upgradeRequest("https://app.example.com")))
+
+	logger.EXPECT().Infof("Rejected WebSocket origin %q from %v", "https://evil.com", "203.0.113.7:51234")
+	assert.False(t, handler.checkOrigin(upgradeRequest("https://evil.com")))
+}
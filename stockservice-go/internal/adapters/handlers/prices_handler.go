@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+// PricesHandler serves the latest known prices over plain HTTP.
+type PricesHandler struct {
+	priceService ports.PriceService
+	logger       ports.Logger
+}
+
+type pricesResponse struct {
+	Prices []*domain.PriceEvent `json:"prices"`
+	// Missing lists requested stocks that have not received a price yet.
+	Missing []domain.Stock `json:"missing,omitempty"`
+}
+
+func NewPricesHandler(ps ports.PriceService, logger ports.Logger) *PricesHandler {
+	return &PricesHandler{
+		priceService: ps,
+		logger:       logger,
+	}
+}
+
+// GetPrice serves GET /api/v1/prices/:stock.
+func (h *PricesHandler) GetPrice(ctx *gin.Context) {
+	stock := domain.Stock(ctx.Param("stock"))
+	if !domain.IsSupportedStock(string(stock)) {
+		ctx.JSON(http.StatusBadRequest, domain.ErrorMessage{
+			Type:    "error",
+			Code:    domain.ErrCodeUnsupportedStock,
+			Message: "Unsupported stock symbol: " + string(stock),
+		})
+		return
+	}
+	if !principalFrom(ctx).Entitled(stock) {
+		ctx.JSON(http.StatusForbidden, notEntitled(stock))
+		return
+	}
+
+	event, ok := h.priceService.LatestPrice(stock)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, domain.ErrorMessage{
+			Type:    "error",
+			Code:    domain.ErrCodeNoPrice,
+			Message: "No price received yet for " + string(stock),
+		})
+		return
+	}
+	ctx.JSON(http.StatusOK, event)
+}
+
+// GetPrices serves GET /api/v1/prices?symbols=BTC-USD,ETH-USD. Without
+// symbols it returns every supported stock the caller is entitled to.
+func (h *PricesHandler) GetPrices(ctx *gin.Context) {
+	principal := principalFrom(ctx)
+	var stocks []domain.Stock
+	for _, stock := range domain.Symbols.Symbols() {
+		if principal.Entitled(stock) {
+			stocks = append(stocks, stock)
+		}
+	}
+	if symbols := ctx.Query("symbols"); symbols != "" {
+		stocks = nil
+		for _, symbol := range strings.Split(symbols, ",") {
+			if symbol = strings.TrimSpace(symbol); symbol != "" {
+				stocks = append(stocks, domain.Stock(symbol))
+			}
+		}
+	}
+
+	response := pricesResponse{Prices: make([]*domain.PriceEvent, 0, len(stocks))}
+	for _, stock := range stocks {
+		if !domain.IsSupportedStock(string(stock)) {
+			ctx.JSON(http.StatusBadRequest, domain.ErrorMessage{
+				Type:    "error",
+				Code:    domain.ErrCodeUnsupportedStock,
+				Message: "Unsupported stock symbol: " + string(stock),
+			})
+			return
+		}
+		if !principal.Entitled(stock) {
+			ctx.JSON(http.StatusForbidden, notEntitled(stock))
+			return
+		}
+
+		if event, ok := h.priceService.LatestPrice(stock); ok {
+			response.Prices = append(response.Prices, event)
+		} else {
+			response.Missing = append(response.Missing, stock)
+		}
+	}
+	ctx.JSON(http.StatusOK, response)
+}
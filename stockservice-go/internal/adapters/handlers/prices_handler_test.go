@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/testutils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func setupPricesRouter(t *testing.T) (*gomock.Controller, *mocks.MockPriceService, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	mockPriceService := mocks.NewMockPriceService(ctrl)
+	handler := NewPricesHandler(mockPriceService, &mocks.StubLogger{})
+
+	router := gin.New()
+	router.GET("/api/v1/prices", handler.GetPrices)
+	router.GET("/api/v1/prices/:stock", handler.GetPrice)
+	return ctrl, mockPriceService, router
+}
+
+func serve(router *gin.Engine, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestGetPrice(t *testing.T) {
+	ctrl, mockPriceService, router := setupPricesRouter(t)
+	defer ctrl.Finish()
+
+	event := testutils.CreateValidPriceEvent()
+	mockPriceService.EXPECT().LatestPrice(domain.StockBitcoin).Return(event, true)
+
+	w := serve(router, "/api/v1/prices/BTC-USD")
+
+	expected, err := json.Marshal(event)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(expected), w.Body.String())
+}
+
+func TestGetPrice_NoPriceYet(t *testing.T) {
+	ctrl, mockPriceService, router := setupPricesRouter(t)
+	defer ctrl.Finish()
+
+	mockPriceService.EXPECT().LatestPrice(domain.StockBitcoin).Return(nil, false)
+
+	w := serve(router, "/api/v1/prices/BTC-USD")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeNoPrice))
+}
+
+func TestGetPrice_UnsupportedStock(t *testing.T) {
+	ctrl, _, router := setupPricesRouter(t)
+	defer ctrl.Finish()
+
+	w := serve(router, "/api/v1/prices/DOGE-USD")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeUnsupportedStock))
+}
+
+func TestGetPrices_WithSymbols(t *testing.T) {
+	ctrl, mockPriceService, router := setupPricesRouter(t)
+	defer ctrl.Finish()
+
+	domain.Symbols.Register("ETH-USD")
+	defer domain.Symbols.Unregister("ETH-USD")
+
+	event := testutils.CreateValidPriceEvent()
+	mockPriceService.EXPECT().LatestPrice(domain.StockBitcoin).Return(event, true)
+	mockPriceService.EXPECT().LatestPrice(domain.Stock("ETH-USD")).Return(nil, false)
+
+	w := serve(router, "/api/v1/prices?symbols=BTC-USD,ETH-USD")
+
+	expected, err := json.Marshal(pricesResponse{
+		Prices:  []*domain.PriceEvent{event},
+		Missing: []domain.Stock{"ETH-USD"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(expected), w.Body.String())
+}
+
+func TestGetPrices_DefaultsToSupportedStocks(t *testing.T) {
+	ctrl, mockPriceService, router := setupPricesRouter(t)
+	defer ctrl.Finish()
+
+	event := testutils.CreateValidPriceEvent()
+	mockPriceService.EXPECT().LatestPrice(domain.StockBitcoin).Return(event, true)
+
+	w := serve(router, "/api/v1/prices")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"ProductID":"BTC-USD"`)
+}
+
+func TestGetPrices_UnsupportedSymbol(t *testing.T) {
+	ctrl, _, router := setupPricesRouter(t)
+	defer ctrl.Finish()
+
+	w := serve(router, "/api/v1/prices?symbols=DOGE-USD")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func setupAuthenticatedRouter(t *testing.T) (*gomock.Controller, *mocks.MockPriceService, *mocks.MockAuthenticator, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	mockPriceService := mocks.NewMockPriceService(ctrl)
+	authenticator := mocks.NewMockAuthenticator(ctrl)
+	prices := NewPricesHandler(mockPriceService, &mocks.StubLogger{})
+	candles := NewCandlesHandler(mockPriceService, &mocks.StubLogger{})
+
+	router := gin.New()
+	api := router.Group("/api/v1", RequireCredentials(authenticator))
+	api.GET("/prices", prices.GetPrices)
+	api.GET("/prices/:stock", prices.GetPrice)
+	api.GET("/candles/:stock", candles.GetCandles)
+	return ctrl, mockPriceService, authenticator, router
+}
+
+func serveWithKey(router *gin.Engine, target, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("X-API-Key", key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRESTRequiresCredentials(t *testing.T) {
+	ctrl, _, authenticator, router := setupAuthenticatedRouter(t)
+	defer ctrl.Finish()
+
+	authenticator.EXPECT().Authenticate(domain.Credentials{}).Return(nil, domain.ErrMissingCredentials)
+
+	w := serve(router, "/api/v1/prices/BTC-USD")
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeUnauthorized))
+}
+
+func TestRESTEnforcesEntitlements(t *testing.T) {
+	ctrl, mockPriceService, authenticator, router := setupAuthenticatedRouter(t)
+	defer ctrl.Finish()
+
+	ethOnly := &domain.Principal{Subject: "partner", Stocks: []domain.Stock{"ETH-USD"}}
+	authenticator.EXPECT().Authenticate(domain.Credentials{APIKey: "key"}).Return(ethOnly, nil).AnyTimes()
+
+	for _, target := range []string{
+		"/api/v1/prices/BTC-USD",
+		"/api/v1/prices?symbols=BTC-USD",
+		"/api/v1/candles/BTC-USD",
+	} {
+		w := serveWithKey(router, target, "key")
+
+		assert.Equal(t, http.StatusForbidden, w.Code, target)
+		assert.Contains(t, w.Body.String(), string(domain.ErrCodeNotEntitled), target)
+	}
+
+	// Without symbols, only entitled stocks are listed.
+	mockPriceService.EXPECT().LatestPrice(gomock.Any()).Times(0)
+	w := serveWithKey(router, "/api/v1/prices", "key")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"prices":[]}`, w.Body.String())
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Now()
+	bucket := newTokenBucket(2, 3, start)
+
+	for i := 0; i < 3; i++ {
+		assert.True(t, bucket.allow(start), "burst message %d", i)
+	}
+	assert.False(t, bucket.allow(start))
+
+	assert.False(t, bucket.allow(start.Add(400*time.Millisecond)))
+	assert.True(t, bucket.allow(start.Add(500*time.Millisecond)))
+
+	// Refills never exceed the burst.
+	later := start.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, bucket.allow(later))
+	}
+	assert.False(t, bucket.allow(later))
+}
+
+func TestConnLimiter(t *testing.T) {
+	limiter := newConnLimiter(2)
+
+	assert.True(t, limiter.acquire("203.0.113.7"))
+	assert.True(t, limiter.acquire("203.0.113.7"))
+	assert.False(t, limiter.acquire("203.0.113.7"))
+	assert.True(t, limiter.acquire("198.51.100.1"))
+
+	limiter.release("203.0.113.7")
+	assert.True(t, limiter.acquire("203.0.113.7"))
+
+	limiter.release("203.0.113.7")
+	limiter.release("203.0.113.7")
+	assert.NotContains(t, limiter.counts, "203.0.113.7")
+}
+
+func TestConnLimiter_Unlimited(t *testing.T) {
+	limiter := newConnLimiter(0)
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, limiter.acquire("203.0.113.7"))
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+)
+
+var errSSEClosed = errors.New("sse stream closed")
+
+type sseAddr string
+
+func (a sseAddr) Network() string { return "tcp" }
+func (a sseAddr) String() string  { return string(a) }
+
+// sseConn adapts a Server-Sent Events response to ports.WebSocketConn so SSE
+// clients go through the same notifier subscriptions as WebSocket clients.
+// Each message becomes one event. Sequences are per product, so the ID of a
+// price event lists the last Sequence sent for every product on the stream,
+// as in "BTC-USD:123,ETH-USD:456", letting browsers resume each product with
+// Last-Event-ID.
+type sseConn struct {
+	w       io.Writer
+	flusher http.Flusher
+	addr    net.Addr
+	// deadline sets the write deadline of the underlying connection, if the
+	// response supports one.
+	deadline func(time.Time) error
+
+	mu        sync.Mutex
+	closed    bool
+	done      chan struct{}
+	positions map[domain.Stock]int64
+}
+
+func newSSEConn(w io.Writer, flusher http.Flusher, remoteAddr string) *sseConn {
+	return &sseConn{
+		w:       w,
+		flusher: flusher,
+		addr:    sseAddr(remoteAddr),
+		done:    make(chan struct{}),
+
+		positions: make(map[domain.Stock]int64),
+	}
+}
+
+// sseEnvelope picks the type that names an unlabelled control message.
+type sseEnvelope struct {
+	Type string `json:"type"`
+}
+
+// ReadMessage blocks until the stream is closed; SSE clients cannot send.
+func (c *sseConn) ReadMessage() (int, []byte, error) {
+	<-c.done
+	return 0, nil, errSSEClosed
+}
+
+// WriteMessage writes a control message sent to this client alone, naming the
+// event after its "type". The notifier labels everything it broadcasts, so
+// shared payloads go through WriteLabeled instead.
+func (c *sseConn) WriteMessage(messageType int, data []byte) error {
+	var envelope sseEnvelope
+	_ = json.Unmarshal(data, &envelope)
+	return c.WriteLabeled(messageType, data, ports.MessageLabel{Type: envelope.Type})
+}
+
+// WriteLabeled writes data as one event. Price updates are "price" events,
+// with an ID when they carry a sequence; other messages are named after their
+// type.
+func (c *sseConn) WriteLabeled(_ int, data []byte, label ports.MessageLabel) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var frame []byte
+	if label.Stock != "" && label.Sequence > 0 {
+		c.positions[label.Stock] = label.Sequence
+		frame = fmt.Appendf(frame, "id: %s\nevent: price\n", formatEventID(c.positions))
+	} else if label.Stock != "" {
+		frame = fmt.Appendf(frame, "event: price\n")
+	} else if label.Type != "" {
+		frame = fmt.Appendf(frame, "event: %s\n", label.Type)
+	}
+	frame = fmt.Appendf(frame, "data: %s\n\n", data)
+
+	return c.writeLocked(frame)
+}
+
+// formatEventID lists positions as "STOCK:SEQUENCE" pairs sorted by stock.
+func formatEventID(positions map[domain.Stock]int64) string {
+	pairs := make([]string, 0, len(positions))
+	for stock, sequence := range positions {
+		pairs = append(pairs, string(stock)+":"+strconv.FormatInt(sequence, 10))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// parseEventID reads an ID written by formatEventID.
+func parseEventID(id string) (map[domain.Stock]int64, error) {
+	positions := make(map[domain.Stock]int64)
+	for _, pair := range strings.Split(id, ",") {
+		stock, value, ok := strings.Cut(pair, ":")
+		if !ok || stock == "" {
+			return nil, fmt.Errorf("invalid position %q", pair)
+		}
+		sequence, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || sequence < 0 {
+			return nil, fmt.Errorf("invalid sequence in %q", pair)
+		}
+		positions[domain.Stock(stock)] = sequence
+	}
+	return positions, nil
+}
+
+func (c *sseConn) writeKeepAlive() error {
+	if err := c.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+		return err
+	}
+	return c.write([]byte(": keepalive\n\n"))
+}
+
+func (c *sseConn) write(frame []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.writeLocked(frame)
+}
+
+func (c *sseConn) writeLocked(frame []byte) error {
+	if c.closed {
+		return errSSEClosed
+	}
+	if _, err := c.w.Write(frame); err != nil {
+		return err
+	}
+	c.flusher.Flush()
+	return nil
+}
+
+func (c *sseConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		close(c.done)
+	}
+	return nil
+}
+
+func (c *sseConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// WriteControl is a no-op: SSE has no control frames, and Close ends the
+// stream.
+func (c *sseConn) WriteControl(_ int, _ []byte, _ time.Time) error {
+	return nil
+}
+
+// SetReadDeadline is a no-op: liveness is tracked by the request context and
+// keep-alive writes.
+func (c *sseConn) SetReadDeadline(_ time.Time) error {
+	return nil
+}
+
+// SetWriteDeadline bounds the writes to the response, so a reader that stalls
+// is dropped as on a WebSocket.
+func (c *sseConn) SetWriteDeadline(t time.Time) error {
+	if c.deadline == nil {
+		return nil
+	}
+	if err := c.deadline(t); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// SetReadLimit is a no-op: SSE clients cannot send messages.
+func (c *sseConn) SetReadLimit(_ int64) {}
+
+func (c *sseConn) SetPongHandler(_ func(appData string) error) {}
+
+// Subprotocol reports no negotiated protocol; SSE streams are always JSON.
+func (c *sseConn) Subprotocol() string {
+	return ""
+}
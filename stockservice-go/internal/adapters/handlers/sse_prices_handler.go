@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+const sseKeepAlivePeriod = 15 * time.Second
+
+// SSEPricesHandler streams live prices as Server-Sent Events for clients that
+// cannot open a WebSocket.
+type SSEPricesHandler struct {
+	priceService  ports.PriceService
+	logger        ports.Logger
+	authenticator ports.Authenticator
+}
+
+type SSEOption func(*SSEPricesHandler)
+
+// WithSSEAuthenticator requires SSE clients to authenticate and only streams
+// symbols they are entitled to.
+func WithSSEAuthenticator(authenticator ports.Authenticator) SSEOption {
+	return func(h *SSEPricesHandler) {
+		h.authenticator = authenticator
+	}
+}
+
+func NewSSEPricesHandler(ps ports.PriceService, logger ports.Logger, opts ...SSEOption) *SSEPricesHandler {
+	h := &SSEPricesHandler{
+		priceService: ps,
+		logger:       logger,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// HandleSSE serves GET /sse/prices?stock=BTC-USD[,ETH-USD]. A Last-Event-ID
+// header holding per-stock positions skips events the client has already
+// received; stocks it does not name start from a snapshot.
+func (h *SSEPricesHandler) HandleSSE(ctx *gin.Context) {
+	principal, err := authenticate(h.authenticator, ctx.Request)
+	if err != nil {
+		h.logger.Infof("Rejected SSE connection from %v: %v", ctx.Request.RemoteAddr, err)
+		ctx.JSON(http.StatusUnauthorized, unauthorized())
+		return
+	}
+
+	stocks, ok := h.parseStocks(ctx)
+	if !ok {
+		return
+	}
+	for _, stock := range stocks {
+		if !principal.Entitled(stock) {
+			ctx.JSON(http.StatusForbidden, notEntitled(stock))
+			return
+		}
+	}
+
+	var positions map[domain.Stock]int64
+	if lastEventID := ctx.GetHeader("Last-Event-ID"); lastEventID != "" {
+		positions, err = parseEventID(lastEventID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, domain.ErrorMessage{
+				Type:    "error",
+				Code:    domain.ErrCodeInvalidMessage,
+				Message: "Last-Event-ID must list STOCK:SEQUENCE positions: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	flusher, ok := ctx.Writer.(http.Flusher)
+	if !ok {
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+	ctx.Status(http.StatusOK)
+	flusher.Flush()
+
+	conn := newSSEConn(ctx.Writer, flusher, ctx.Request.RemoteAddr)
+	conn.deadline = http.NewResponseController(ctx.Writer).SetWriteDeadline
+	// Carry resumed positions over so the next ID still names quiet stocks.
+	for _, stock := range stocks {
+		if sequence, ok := positions[stock]; ok {
+			conn.positions[stock] = sequence
+		}
+	}
+	h.logger.Infof("New SSE client connected: %v", conn.RemoteAddr())
+	h.priceService.AddClient(conn)
+	defer func() {
+		h.priceService.RemoveClient(conn)
+		_ = conn.Close()
+		h.logger.Infof("SSE client disconnected: %v", conn.RemoteAddr())
+	}()
+
+	for _, stock := range stocks {
+		opts := domain.SubscriptionOptions{ResumeFrom: positions[stock]}
+		if err := h.priceService.Subscribe(conn, stock, opts); err != nil {
+			h.logger.Errorf("SSE client %v failed to subscribe to %v: %v", conn.RemoteAddr(), stock, err)
+			_ = h.priceService.Send(conn, domain.ErrorMessage{
+				Type:    "error",
+				Code:    domain.ErrCodeSubscribeFailed,
+				Message: err.Error(),
+			})
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlivePeriod)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-conn.done:
+			return
+		case <-keepAlive.C:
+			if err := conn.writeKeepAlive(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *SSEPricesHandler) parseStocks(ctx *gin.Context) ([]domain.Stock, bool) {
+	var stocks []domain.Stock
+	for _, value := range ctx.QueryArray("stock") {
+		for _, stock := range strings.Split(value, ",") {
+			if stock = strings.TrimSpace(stock); stock != "" {
+				stocks = append(stocks, domain.Stock(stock))
+			}
+		}
+	}
+
+	if len(stocks) == 0 {
+		ctx.JSON(http.StatusBadRequest, domain.ErrorMessage{
+			Type:    "error",
+			Code:    domain.ErrCodeInvalidMessage,
+			Message: "At least one stock query parameter is required",
+		})
+		return nil, false
+	}
+
+	for _, stock := range stocks {
+		if !domain.IsSupportedStock(string(stock)) {
+			ctx.JSON(http.StatusBadRequest, domain.ErrorMessage{
+				Type:    "error",
+				Code:    domain.ErrCodeUnsupportedStock,
+				Message: "Unsupported stock symbol: " + string(stock),
+			})
+			return nil, false
+		}
+	}
+	return stocks, true
+}
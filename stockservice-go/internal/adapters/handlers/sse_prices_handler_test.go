@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/testutils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func newSSERequest(t *testing.T, target string) (*gin.Context, *httptest.ResponseRecorder, context.CancelFunc) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodGet, target, nil).WithContext(reqCtx)
+	ctx.Request = req
+	return ctx, w, cancel
+}
+
+func priceLabel(event *domain.PriceEvent) ports.MessageLabel {
+	return ports.MessageLabel{Stock: event.ProductID, Sequence: event.Sequence}
+}
+
+func TestSSEConn_WriteLabeled_PriceEvent(t *testing.T) {
+	w := httptest.NewRecorder()
+	conn := newSSEConn(w, w, "127.0.0.1:12345")
+
+	event := testutils.CreateValidPriceEvent()
+	data, err := json.Marshal(event)
+	assert.NoError(t, err)
+
+	err = conn.WriteLabeled(websocket.TextMessage, data, priceLabel(event))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id: BTC-USD:100\nevent: price\ndata: "+string(data)+"\n\n", w.Body.String())
+}
+
+func TestSSEConn_WriteLabeled_IDTracksEveryProduct(t *testing.T) {
+	w := httptest.NewRecorder()
+	conn := newSSEConn(w, w, "127.0.0.1:12345")
+
+	for _, event := range []*domain.PriceEvent{
+		{ProductID: "ETH-USD", Sequence: 7},
+		{ProductID: domain.StockBitcoin, Sequence: 100},
+		{ProductID: "ETH-USD", Sequence: 9},
+	} {
+		data, err := json.Marshal(event)
+		assert.NoError(t, err)
+		assert.NoError(t, conn.WriteLabeled(websocket.TextMessage, data, priceLabel(event)))
+	}
+
+	assert.Contains(t, w.Body.String(), "id: ETH-USD:7\n")
+	assert.Contains(t, w.Body.String(), "id: BTC-USD:100,ETH-USD:7\n")
+	assert.Contains(t, w.Body.String(), "id: BTC-USD:100,ETH-USD:9\n")
+}
+
+func TestSSEConn_WriteLabeled_StatusIsNotAPrice(t *testing.T) {
+	w := httptest.NewRecorder()
+	conn := newSSEConn(w, w, "127.0.0.1:12345")
+
+	data, err := json.Marshal(domain.StatusMessage{Type: domain.StatusType, Status: domain.StatusSequenceRegression, Stock: domain.StockBitcoin, Sequence: 5})
+	assert.NoError(t, err)
+
+	assert.NoError(t, conn.WriteLabeled(websocket.TextMessage, data, ports.MessageLabel{Type: domain.StatusType}))
+	assert.Equal(t, "event: status\ndata: "+string(data)+"\n\n", w.Body.String())
+}
+
+func TestParseEventID(t *testing.T) {
+	positions, err := parseEventID("BTC-USD:100,ETH-USD:9")
+	assert.NoError(t, err)
+	assert.Equal(t, map[domain.Stock]int64{domain.StockBitcoin: 100, "ETH-USD": 9}, positions)
+
+	for _, id := range []string{"99", "BTC-USD:", ":5", "BTC-USD:x", "BTC-USD:-1"} {
+		_, err := parseEventID(id)
+		assert.Error(t, err, id)
+	}
+}
+
+func TestSSEConn_WriteMessage_ControlMessage(t *testing.T) {
+	w := httptest.NewRecorder()
+	conn := newSSEConn(w, w, "127.0.0.1:12345")
+
+	data, err := json.Marshal(domain.ErrorMessage{Type: "error", Message: "boom"})
+	assert.NoError(t, err)
+
+	err = conn.WriteMessage(websocket.TextMessage, data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "event: error\ndata: "+string(data)+"\n\n", w.Body.String())
+}
+
+func TestSSEConn_WriteAfterClose(t *testing.T) {
+	w := httptest.NewRecorder()
+	conn := newSSEConn(w, w, "127.0.0.1:12345")
+
+	assert.NoError(t, conn.Close())
+	_, _, readErr := conn.ReadMessage()
+
+	assert.ErrorIs(t, conn.WriteMessage(websocket.TextMessage, []byte(`{}`)), errSSEClosed)
+	assert.ErrorIs(t, readErr, errSSEClosed)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestHandleSSE_StreamsSubscribedPrices(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPriceService := mocks.NewMockPriceService(ctrl)
+	handler := NewSSEPricesHandler(mockPriceService, &mocks.StubLogger{})
+	ctx, w, cancel := newSSERequest(t, "/sse/prices?stock=BTC-USD")
+	ctx.Request.Header.Set("Last-Event-ID", "BTC-USD:99")
+
+	event := testutils.CreateValidPriceEvent()
+	data, err := json.Marshal(event)
+	assert.NoError(t, err)
+
+	mockPriceService.EXPECT().AddClient(gomock.Any())
+	mockPriceService.EXPECT().
+		Subscribe(gomock.Any(), domain.StockBitcoin, domain.SubscriptionOptions{ResumeFrom: 99}).
+		DoAndReturn(func(conn *sseConn, _ domain.Stock, _ domain.SubscriptionOptions) error {
+			defer cancel()
+			return conn.WriteLabeled(websocket.TextMessage, data, priceLabel(event))
+		})
+	mockPriceService.EXPECT().RemoveClient(gomock.Any())
+
+	handler.HandleSSE(ctx)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id: BTC-USD:100\nevent: price\ndata: "+string(data)+"\n\n", w.Body.String())
+}
+
+func TestHandleSSE_ResumesEachStockFromItsOwnPosition(t *testing.T) {
+	domain.Symbols.Register("ETH-USD", "SOL-USD")
+	t.Cleanup(func() {
+		domain.Symbols.Unregister("ETH-USD")
+		domain.Symbols.Unregister("SOL-USD")
+	})
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPriceService := mocks.NewMockPriceService(ctrl)
+	handler := NewSSEPricesHandler(mockPriceService, &mocks.StubLogger{})
+	ctx, _, cancel := newSSERequest(t, "/sse/prices?stock=BTC-USD,ETH-USD,SOL-USD")
+	ctx.Request.Header.Set("Last-Event-ID", "BTC-USD:99,ETH-USD:7")
+
+	mockPriceService.EXPECT().AddClient(gomock.Any())
+	mockPriceService.EXPECT().Subscribe(gomock.Any(), domain.StockBitcoin, domain.SubscriptionOptions{ResumeFrom: 99})
+	mockPriceService.EXPECT().Subscribe(gomock.Any(), domain.Stock("ETH-USD"), domain.SubscriptionOptions{ResumeFrom: 7})
+	mockPriceService.EXPECT().
+		Subscribe(gomock.Any(), domain.Stock("SOL-USD"), domain.SubscriptionOptions{}).
+		DoAndReturn(func(_ *sseConn, _ domain.Stock, _ domain.SubscriptionOptions) error {
+			cancel()
+			return nil
+		})
+	mockPriceService.EXPECT().RemoveClient(gomock.Any())
+
+	handler.HandleSSE(ctx)
+}
+
+func TestHandleSSE_UnsupportedStock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewSSEPricesHandler(mocks.NewMockPriceService(ctrl), &mocks.StubLogger{})
+	ctx, w, cancel := newSSERequest(t, "/sse/prices?stock=DOGE-USD")
+	defer cancel()
+
+	handler.HandleSSE(ctx)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeUnsupportedStock))
+}
+
+func TestHandleSSE_InvalidLastEventID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler := NewSSEPricesHandler(mocks.NewMockPriceService(ctrl), &mocks.StubLogger{})
+	ctx, w, cancel := newSSERequest(t, "/sse/prices?stock=BTC-USD")
+	defer cancel()
+	ctx.Request.Header.Set("Last-Event-ID", "not-a-number")
+
+	handler.HandleSSE(ctx)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandleSSE_Unauthenticated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authenticator := mocks.NewMockAuthenticator(ctrl)
+	authenticator.EXPECT().Authenticate(domain.Credentials{}).Return(nil, domain.ErrMissingCredentials)
+	handler := NewSSEPricesHandler(mocks.NewMockPriceService(ctrl), &mocks.StubLogger{}, WithSSEAuthenticator(authenticator))
+	ctx, w, cancel := newSSERequest(t, "/sse/prices?stock=BTC-USD")
+	defer cancel()
+
+	handler.HandleSSE(ctx)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeUnauthorized))
+}
+
+func TestHandleSSE_NotEntitled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	originalIsSupportedStock := domain.IsSupportedStock
+	domain.IsSupportedStock = func(stock string) bool { return true }
+	defer func() { domain.IsSupportedStock = originalIsSupportedStock }()
+
+	authenticator := mocks.NewMockAuthenticator(ctrl)
+	authenticator.EXPECT().Authenticate(domain.Credentials{Token: "t0ken"}).
+		Return(&domain.Principal{Subject: "alice", Stocks: []domain.Stock{domain.StockBitcoin}}, nil)
+	handler := NewSSEPricesHandler(mocks.NewMockPriceService(ctrl), &mocks.StubLogger{}, WithSSEAuthenticator(authenticator))
+	ctx, w, cancel := newSSERequest(t, "/sse/prices?stock=BTC-USD,ETH-USD&access_token=t0ken")
+	defer cancel()
+
+	handler.HandleSSE(ctx)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), string(domain.ErrCodeNotEntitled))
+}
@@ -2,58 +2,374 @@ package kafka
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/dtos"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
 )
 
+// deadLetterTimeout bounds how long a failed message may hold up the
+// consumer while it is dead-lettered.
+const deadLetterTimeout = 10 * time.Second
+
+const (
+	defaultCommitInterval = time.Second
+	// Failed messages are retried after retryBaseDelay, doubling up to
+	// retryMaxDelay.
+	retryBaseDelay = 100 * time.Millisecond
+	retryMaxDelay  = 5 * time.Second
+
+	// Failed reads are retried after a jittered delay starting at
+	// readRetryBaseDelay and doubling up to readRetryMaxDelay. The feed is
+	// degraded from the first failure and disconnected after
+	// disconnectedAfter failures in a row.
+	readRetryBaseDelay = 500 * time.Millisecond
+	readRetryMaxDelay  = 30 * time.Second
+	disconnectedAfter  = 5
+
+	// readerStatsInterval is how often the reader's fetch statistics are
+	// checked. It exceeds the reader's default MaxWait of 10s so a healthy
+	// reader fetches at least once per interval, even on a quiet topic.
+	readerStatsInterval = 15 * time.Second
+)
+
+// messageReader is the part of kafka.Reader the consumer uses.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Stats() kafka.ReaderStats
+	Close() error
+}
+
+// messageWriter is the part of kafka.Writer used for dead-lettering.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// settledError is a failure after which a message is done with: it can never
+// be processed and was dead-lettered, or dropped when no dead-letter topic is
+// configured. Its offset may be committed.
+type settledError struct {
+	err error
+}
+
+func (e *settledError) Error() string { return e.err.Error() }
+func (e *settledError) Unwrap() error { return e.err }
+
 type BitcoinPriceConsumer struct {
-	reader  *kafka.Reader
-	handler func(event *domain.PriceEvent) error
-	logger  ports.Logger
+	// reader is created by Start, once the group has been moved to its start
+	// position.
+	reader       messageReader
+	readerConfig kafka.ReaderConfig
+	handler      func(event *domain.PriceEvent) error
+	onStatus     func(status domain.FeedStatus)
+	logger       ports.Logger
+
+	// In group mode kafka-go retries broker failures internally and only
+	// reports them to its ErrorLogger, so status is driven from there and
+	// from the reader's statistics as well as from FetchMessage.
+	statusMu       sync.Mutex
+	status         domain.FeedStatus
+	readFailures   int
+	readRetryDelay time.Duration
+	statsInterval  time.Duration
+
+	startPosition StartPosition
+	offsets       offsetClient
+
+	commitInterval time.Duration
+	retryDelay     time.Duration
+
+	tls  *tls.Config
+	sasl sasl.Mechanism
+
+	deadLetterTopic string
+	deadLetters     messageWriter
 }
 
-func NewBitcoinPriceConsumer(brokerURL, topic, groupID string, logger ports.Logger) *BitcoinPriceConsumer {
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: []string{brokerURL},
-		GroupID: groupID,
-		Topic:   topic,
-	})
+type Option func(*BitcoinPriceConsumer)
 
-	return &BitcoinPriceConsumer{
-		reader: reader,
-		logger: logger,
+// WithDeadLetterTopic publishes messages that cannot be decoded to topic
+// instead of dropping them.
+func WithDeadLetterTopic(topic string) Option {
+	return func(c *BitcoinPriceConsumer) {
+		c.deadLetterTopic = topic
 	}
 }
 
+// WithCommitInterval batches offset commits and sends them every interval.
+// Zero commits every message synchronously.
+func WithCommitInterval(interval time.Duration) Option {
+	return func(c *BitcoinPriceConsumer) {
+		c.commitInterval = interval
+	}
+}
+
+// WithStartPosition moves the consumer group to pos each time the consumer
+// starts, instead of resuming from its committed offsets.
+func WithStartPosition(pos StartPosition) Option {
+	return func(c *BitcoinPriceConsumer) {
+		c.startPosition = pos
+	}
+}
+
+// WithTLS connects to the brokers over TLS.
+func WithTLS(config *tls.Config) Option {
+	return func(c *BitcoinPriceConsumer) {
+		c.tls = config
+	}
+}
+
+// WithSASL authenticates to the brokers with mechanism.
+func WithSASL(mechanism sasl.Mechanism) Option {
+	return func(c *BitcoinPriceConsumer) {
+		c.sasl = mechanism
+	}
+}
+
+// NewBitcoinPriceConsumer consumes topic as groupID. brokers are the
+// bootstrap brokers; the rest of the cluster is discovered from them.
+func NewBitcoinPriceConsumer(brokers []string, topic, groupID string, logger ports.Logger, opts ...Option) *BitcoinPriceConsumer {
+	c := &BitcoinPriceConsumer{
+		logger:         logger,
+		status:         domain.StatusDisconnected,
+		commitInterval: defaultCommitInterval,
+		retryDelay:     retryBaseDelay,
+		readRetryDelay: readRetryBaseDelay,
+		statsInterval:  readerStatsInterval,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// The reader dials brokers itself; the dead-letter writer and the offset
+	// client share a transport. Both carry the same TLS and SASL settings.
+	dialer := &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		TLS:           c.tls,
+		SASLMechanism: c.sasl,
+	}
+	transport := &kafka.Transport{
+		TLS:  c.tls,
+		SASL: c.sasl,
+	}
+
+	// Pending commits are flushed when the reader is closed.
+	c.readerConfig = kafka.ReaderConfig{
+		Brokers:        brokers,
+		GroupID:        groupID,
+		Topic:          topic,
+		Dialer:         dialer,
+		CommitInterval: c.commitInterval,
+		ErrorLogger:    kafka.LoggerFunc(c.readerError),
+	}
+	c.offsets = &kafka.Client{Addr: kafka.TCP(brokers...), Transport: transport}
+	if c.deadLetterTopic != "" {
+		c.deadLetters = &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  c.deadLetterTopic,
+			Transport:              transport,
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		}
+	}
+	return c
+}
+
 func (c *BitcoinPriceConsumer) SetListener(handlePriceEvent func(event *domain.PriceEvent) error) {
 	c.handler = handlePriceEvent
 }
 
+func (c *BitcoinPriceConsumer) SetStatusListener(handleStatus func(status domain.FeedStatus)) {
+	c.onStatus = handleStatus
+}
+
+// Start consumes messages until ctx is done. A message's offset is committed
+// only once it has been handled or dead-lettered, so every message is
+// delivered at least once.
 func (c *BitcoinPriceConsumer) Start(ctx context.Context) error {
+	if c.reader == nil {
+		if err := c.seek(ctx); err != nil {
+			return err
+		}
+		c.reader = kafka.NewReader(c.readerConfig)
+	}
+
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	defer stopWatching()
+	go c.watchReader(watchCtx)
+
 	defer func() {
 		if err := c.reader.Close(); err != nil {
 			c.logger.Errorf("Error closing Kafka reader: %v", err)
 		}
+		if c.deadLetters != nil {
+			if err := c.deadLetters.Close(); err != nil {
+				c.logger.Errorf("Error closing dead-letter writer: %v", err)
+			}
+		}
 	}()
 	for {
-		msg, err := c.reader.ReadMessage(ctx)
+		msg, err := c.reader.FetchMessage(ctx)
 		if err != nil {
-			if errors.Is(err, context.Canceled) {
+			if errors.Is(err, context.Canceled) || ctx.Err() != nil {
+				c.logger.Info("BitcoinPriceConsumer context canceled")
+				return nil
+			}
+			if !c.backOff(ctx, err) {
 				c.logger.Info("BitcoinPriceConsumer context canceled")
 				return nil
 			}
-			c.logger.Errorf("Error reading message: %v", err)
 			continue
 		}
+		c.readSucceeded()
 
-		if err := c.ProcessMessage(msg); err != nil {
-			continue
+		if !c.processUntilSettled(ctx, msg) {
+			c.logger.Info("BitcoinPriceConsumer context canceled")
+			return nil
 		}
+
+		// Not ctx: the commit of the last message must still be queued while
+		// shutting down.
+		if err := c.reader.CommitMessages(context.Background(), msg); err != nil {
+			c.logger.Errorf("Error committing offset %d of partition %d: %v", msg.Offset, msg.Partition, err)
+		}
+	}
+}
+
+// backOff records a failed read and waits before the next one. It returns
+// false if ctx is done first.
+func (c *BitcoinPriceConsumer) backOff(ctx context.Context, err error) bool {
+	failures := c.readFailed()
+
+	delay := c.readRetryDelay
+	for i := 1; i < failures && delay < readRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	delay = jitter(min(delay, readRetryMaxDelay))
+	c.logger.Errorf("Error reading message (failure %d, retrying in %v): %v", failures, delay, err)
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
+
+// jitter spreads d over [d/2, d] so that consumers failing together do not
+// retry together.
+func jitter(d time.Duration) time.Duration {
+	return d/2 + rand.N(d/2+1)
+}
+
+// readerError is the reader's ErrorLogger. kafka-go reports every failed
+// attempt to join the group or reach a partition leader through it.
+func (c *BitcoinPriceConsumer) readerError(format string, args ...interface{}) {
+	c.logger.Errorf(format, args...)
+	c.readFailed()
+}
+
+// watchReader marks the feed connected whenever the reader has fetched from
+// a broker without error over an interval, so a quiet topic does not stay
+// degraded after an outage. It runs until ctx is done.
+func (c *BitcoinPriceConsumer) watchReader(ctx context.Context) {
+	ticker := time.NewTicker(c.statsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if stats := c.reader.Stats(); stats.Fetches > 0 && stats.Errors == 0 {
+				c.readSucceeded()
+			}
+		}
+	}
+}
+
+// readFailed records a failed read and returns the number of failures in a
+// row.
+func (c *BitcoinPriceConsumer) readFailed() int {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+
+	c.readFailures++
+	if c.readFailures >= disconnectedAfter {
+		c.setStatus(domain.StatusDisconnected)
+	} else {
+		c.setStatus(domain.StatusDegraded)
+	}
+	return c.readFailures
+}
+
+func (c *BitcoinPriceConsumer) readSucceeded() {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+
+	c.readFailures = 0
+	c.setStatus(domain.StatusConnected)
+}
+
+// setStatus must be called with statusMu held, which also keeps listener
+// calls in order.
+func (c *BitcoinPriceConsumer) setStatus(status domain.FeedStatus) {
+	if status == c.status {
+		return
+	}
+	c.logger.Infof("Kafka feed %v -> %v", c.status, status)
+	c.status = status
+	if c.onStatus != nil {
+		c.onStatus(status)
+	}
+}
+
+// seek moves the consumer group to the configured start position. It must run
+// before the reader joins the group.
+func (c *BitcoinPriceConsumer) seek(ctx context.Context) error {
+	if c.startPosition.Mode == "" || c.startPosition.Mode == StartCommitted {
+		return nil
+	}
+
+	offsets, err := seekGroup(ctx, c.offsets, c.readerConfig.Topic, c.readerConfig.GroupID, c.startPosition)
+	if err != nil {
+		return fmt.Errorf("seeking to %v start position: %w", c.startPosition.Mode, err)
+	}
+	c.logger.Infof("Consumer group %v starts from %v offsets %v", c.readerConfig.GroupID, c.startPosition.Mode, offsets)
+	return nil
+}
+
+// processUntilSettled processes msg, retrying with backoff until it is
+// handled or settled. It returns false if ctx is done first, leaving msg
+// uncommitted so it is redelivered.
+func (c *BitcoinPriceConsumer) processUntilSettled(ctx context.Context, msg kafka.Message) bool {
+	delay := c.retryDelay
+	for {
+		err := c.ProcessMessage(msg)
+		var settled *settledError
+		if err == nil || errors.As(err, &settled) {
+			return true
+		}
+
+		c.logger.Infof("Retrying message at partition %d offset %d in %v", msg.Partition, msg.Offset, delay)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, retryMaxDelay)
 	}
 }
 
@@ -63,15 +379,21 @@ func (c *BitcoinPriceConsumer) ProcessMessage(msg kafka.Message) error {
 	var eventDTO dtos.PriceEventDTO
 	if err := json.Unmarshal(msg.Value, &eventDTO); err != nil {
 		c.logger.Errorf("Error unmarshalling message: %v", err)
-		return err
+		return c.deadLetter(msg, err)
 	}
 
 	c.logger.Debugf("🚀 🚀 🚀 BTC Price Event received 🚀 🚀 🚀 %s", eventDTO.FormatLog())
 
 	priceEvent, err := dtos.ToPriceEvent(&eventDTO)
+	if errors.Is(err, dtos.ErrUnsupportedStock) {
+		// The topic may carry products the service does not serve, or no
+		// longer serves since an operator removed them.
+		c.logger.Debugf("Skipping message at offset %d: %v", msg.Offset, err)
+		return nil
+	}
 	if err != nil {
 		c.logger.Errorf("Error converting PriceEventDTO -> PriceEvent message: %v", err)
-		return err
+		return c.deadLetter(msg, err)
 	}
 
 	if err := c.handler(priceEvent); err != nil {
@@ -82,3 +404,34 @@ func (c *BitcoinPriceConsumer) ProcessMessage(msg kafka.Message) error {
 	c.logger.Debugf("Processed message at offset %d", msg.Offset)
 	return nil
 }
+
+// deadLetter publishes msg to the dead-letter topic, if one is configured. The
+// original payload is kept as the value for replay, and repeated in a header
+// next to where it came from and why it failed. It returns cause as a
+// settledError unless publishing fails.
+func (c *BitcoinPriceConsumer) deadLetter(msg kafka.Message, cause error) error {
+	if c.deadLetters == nil {
+		return &settledError{err: cause}
+	}
+
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: "dlq-original-topic", Value: []byte(msg.Topic)},
+		kafka.Header{Key: "dlq-original-partition", Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: "dlq-original-offset", Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: "dlq-original-timestamp", Value: []byte(msg.Time.UTC().Format(time.RFC3339Nano))},
+		kafka.Header{Key: "dlq-original-payload", Value: msg.Value},
+		kafka.Header{Key: "dlq-error", Value: []byte(cause.Error())},
+		kafka.Header{Key: "dlq-failed-at", Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), deadLetterTimeout)
+	defer cancel()
+	err := c.deadLetters.WriteMessages(ctx, kafka.Message{Key: msg.Key, Value: msg.Value, Headers: headers})
+	if err != nil {
+		c.logger.Errorf("Error dead-lettering message at partition %d offset %d: %v", msg.Partition, msg.Offset, err)
+		return fmt.Errorf("%w (dead-lettering failed: %v)", cause, err)
+	}
+	c.logger.Infof("Dead-lettered message at partition %d offset %d to %v", msg.Partition, msg.Offset, c.deadLetterTopic)
+	return &settledError{err: cause}
+}
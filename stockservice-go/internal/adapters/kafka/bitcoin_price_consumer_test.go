@@ -1,9 +1,13 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/dtos"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
@@ -17,8 +21,10 @@ import (
 
 func setupConsumer(handler func(event *domain.PriceEvent) error) *BitcoinPriceConsumer {
 	return &BitcoinPriceConsumer{
-		logger:  &mocks.StubLogger{},
-		handler: handler,
+		logger:        &mocks.StubLogger{},
+		handler:       handler,
+		status:        domain.StatusDisconnected,
+		statsInterval: time.Hour,
 	}
 }
 
@@ -92,3 +98,307 @@ func TestBitcoinPriceConsumer_ProcessMessage_HandlerError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, handlerErr, err)
 }
+
+type fakeWriter struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error { return nil }
+
+func headerMap(headers []kafka.Header) map[string]string {
+	m := make(map[string]string)
+	for _, h := range headers {
+		m[h.Key] = string(h.Value)
+	}
+	return m
+}
+
+func TestBitcoinPriceConsumer_ProcessMessage_DeadLettersUndecodableMessages(t *testing.T) {
+	handlerCalled := false
+	consumer := setupConsumer(func(event *domain.PriceEvent) error {
+		handlerCalled = true
+		return nil
+	})
+	dlq := &fakeWriter{}
+	consumer.deadLetters = dlq
+
+	sentAt := time.Date(2024, 4, 27, 14, 0, 0, 0, time.UTC)
+	unconvertible, _ := json.Marshal(dtos.PriceEventDTO{Price: ""})
+	msgs := []kafka.Message{
+		{Topic: "prices", Partition: 2, Offset: 41, Time: sentAt, Key: []byte("BTC-USD"), Value: []byte(`invalid json`)},
+		{Topic: "prices", Partition: 2, Offset: 42, Time: sentAt, Value: unconvertible},
+	}
+
+	for _, msg := range msgs {
+		assert.Error(t, consumer.ProcessMessage(msg))
+	}
+
+	assert.False(t, handlerCalled)
+	assert.Len(t, dlq.messages, len(msgs))
+	for i, dead := range dlq.messages {
+		headers := headerMap(dead.Headers)
+		assert.Equal(t, msgs[i].Value, dead.Value)
+		assert.Equal(t, msgs[i].Key, dead.Key)
+		assert.Equal(t, string(msgs[i].Value), headers["dlq-original-payload"])
+		assert.Equal(t, "prices", headers["dlq-original-topic"])
+		assert.Equal(t, "2", headers["dlq-original-partition"])
+		assert.Equal(t, strconv.FormatInt(msgs[i].Offset, 10), headers["dlq-original-offset"])
+		assert.Equal(t, "2024-04-27T14:00:00Z", headers["dlq-original-timestamp"])
+		assert.NotEmpty(t, headers["dlq-error"])
+		assert.NotEmpty(t, headers["dlq-failed-at"])
+	}
+}
+
+func TestBitcoinPriceConsumer_ProcessMessage_SkipsUnsupportedStock(t *testing.T) {
+	handlerCalled := false
+	consumer := setupConsumer(func(event *domain.PriceEvent) error {
+		handlerCalled = true
+		return nil
+	})
+	dlq := &fakeWriter{}
+	consumer.deadLetters = dlq
+	eventDTO := testutils.CreateValidPriceEventDTO()
+	eventDTO.ProductID = "XRP-USD"
+
+	err := consumer.ProcessMessage(createKafkaMessage(eventDTO))
+
+	assert.NoError(t, err)
+	assert.False(t, handlerCalled)
+	assert.Empty(t, dlq.messages)
+}
+
+func TestBitcoinPriceConsumer_ProcessMessage_HandlerErrorIsNotDeadLettered(t *testing.T) {
+	consumer := setupConsumer(func(event *domain.PriceEvent) error { return errors.New("handler failed") })
+	dlq := &fakeWriter{}
+	consumer.deadLetters = dlq
+
+	err := consumer.ProcessMessage(createKafkaMessage(testutils.CreateValidPriceEventDTO()))
+
+	assert.Error(t, err)
+	assert.Empty(t, dlq.messages)
+}
+
+func TestBitcoinPriceConsumer_ProcessMessage_DeadLetterWriteFailure(t *testing.T) {
+	consumer := setupConsumer(func(event *domain.PriceEvent) error { return nil })
+	writeErr := errors.New("broker unavailable")
+	consumer.deadLetters = &fakeWriter{err: writeErr}
+
+	err := consumer.ProcessMessage(kafka.Message{Value: []byte(`invalid json`)})
+
+	assert.ErrorContains(t, err, writeErr.Error())
+}
+
+// fakeReader serves queued messages and blocks once they run out.
+type fakeReader struct {
+	mu        sync.Mutex
+	queue     []kafka.Message
+	errs      []error
+	fetches   int64
+	committed []int64
+	closed    bool
+	drained   chan struct{}
+}
+
+func newFakeReader(msgs ...kafka.Message) *fakeReader {
+	return &fakeReader{queue: msgs, drained: make(chan struct{})}
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		r.mu.Unlock()
+		return kafka.Message{}, err
+	}
+	if len(r.queue) > 0 {
+		msg := r.queue[0]
+		r.queue = r.queue[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+	close(r.drained)
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range msgs {
+		r.committed = append(r.committed, msg.Offset)
+	}
+	return nil
+}
+
+// Stats reports the fetches made since the last call, as kafka-go does.
+func (r *fakeReader) Stats() kafka.ReaderStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := kafka.ReaderStats{Fetches: r.fetches}
+	r.fetches = 0
+	return stats
+}
+
+func (r *fakeReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+func runUntilDrained(t *testing.T, consumer *BitcoinPriceConsumer, reader *fakeReader) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Start(ctx) }()
+
+	select {
+	case <-reader.drained:
+	case <-time.After(time.Second):
+		t.Fatal("consumer did not process every message")
+	}
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestBitcoinPriceConsumer_Start_CommitsAfterHandling(t *testing.T) {
+	attempts := 0
+	consumer := setupConsumer(func(event *domain.PriceEvent) error {
+		attempts++
+		if attempts == 2 {
+			return errors.New("transient failure")
+		}
+		return nil
+	})
+	consumer.retryDelay = time.Millisecond
+	valid := createKafkaMessage(testutils.CreateValidPriceEventDTO())
+	first, second := valid, valid
+	first.Offset, second.Offset = 1, 2
+	undecodable := kafka.Message{Offset: 3, Value: []byte(`invalid json`)}
+	reader := newFakeReader(first, second, undecodable)
+	consumer.reader = reader
+
+	runUntilDrained(t, consumer, reader)
+
+	assert.Equal(t, 3, attempts, "the failed message is retried")
+	assert.Equal(t, []int64{1, 2, 3}, reader.committed, "undecodable messages are dropped without a dead-letter topic")
+	assert.True(t, reader.closed)
+}
+
+func TestBitcoinPriceConsumer_Start_DoesNotCommitUnsettledMessageOnShutdown(t *testing.T) {
+	consumer := setupConsumer(func(event *domain.PriceEvent) error { return nil })
+	consumer.retryDelay = time.Millisecond
+	dlq := &fakeWriter{err: errors.New("broker unavailable")}
+	consumer.deadLetters = dlq
+	reader := newFakeReader(kafka.Message{Offset: 7, Value: []byte(`invalid json`)})
+	consumer.reader = reader
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, consumer.Start(ctx))
+
+	assert.Empty(t, reader.committed)
+	assert.True(t, reader.closed)
+}
+
+func TestBitcoinPriceConsumer_Start_BacksOffAndReportsFeedStatus(t *testing.T) {
+	consumer := setupConsumer(func(event *domain.PriceEvent) error { return nil })
+	consumer.readRetryDelay = time.Millisecond
+	var statuses []domain.FeedStatus
+	consumer.SetStatusListener(func(status domain.FeedStatus) { statuses = append(statuses, status) })
+	reader := newFakeReader(createKafkaMessage(testutils.CreateValidPriceEventDTO()))
+	for range disconnectedAfter {
+		reader.errs = append(reader.errs, errors.New("broker unreachable"))
+	}
+	consumer.reader = reader
+
+	runUntilDrained(t, consumer, reader)
+
+	assert.Equal(t, []domain.FeedStatus{
+		domain.StatusDegraded,
+		domain.StatusDisconnected,
+		domain.StatusConnected,
+	}, statuses)
+	assert.Zero(t, consumer.readFailures)
+}
+
+func TestBitcoinPriceConsumer_Start_ReportsReaderErrorsWhileFetchBlocks(t *testing.T) {
+	consumer := setupConsumer(func(event *domain.PriceEvent) error { return nil })
+	consumer.statsInterval = time.Millisecond
+	statuses := make(chan domain.FeedStatus, 10)
+	consumer.SetStatusListener(func(status domain.FeedStatus) { statuses <- status })
+	reader := newFakeReader()
+	consumer.reader = reader
+	errorLogger := kafka.LoggerFunc(consumer.readerError)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Start(ctx) }()
+	<-reader.drained
+
+	// Nothing has been fetched yet, so the feed is not connected.
+	select {
+	case status := <-statuses:
+		t.Fatalf("unexpected status %v before any fetch", status)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// kafka-go retries failed group joins itself and only logs them.
+	for range disconnectedAfter {
+		errorLogger.Printf("unable to join group: %v", errors.New("connection refused"))
+	}
+	assert.Equal(t, domain.StatusDegraded, <-statuses)
+	assert.Equal(t, domain.StatusDisconnected, <-statuses)
+
+	// The broker is back: the reader fetches, but the topic stays quiet.
+	reader.mu.Lock()
+	reader.fetches = 1
+	reader.mu.Unlock()
+	select {
+	case status := <-statuses:
+		assert.Equal(t, domain.StatusConnected, status)
+	case <-time.After(time.Second):
+		t.Fatal("feed was not reported connected after a clean fetch")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestBitcoinPriceConsumer_Start_StopsBackingOffOnShutdown(t *testing.T) {
+	consumer := setupConsumer(func(event *domain.PriceEvent) error { return nil })
+	consumer.readRetryDelay = time.Hour
+	reader := newFakeReader()
+	reader.errs = []error{errors.New("broker unreachable")}
+	consumer.reader = reader
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Start(ctx) }()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("consumer kept backing off after shutdown")
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for range 100 {
+		delay := jitter(time.Second)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		assert.LessOrEqual(t, delay, time.Second)
+	}
+}
@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate and its key as PEM files.
+func writeCertificate(t *testing.T) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "stockservice"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+
+	config, err := NewTLSConfig(certFile, certFile, keyFile)
+
+	require.NoError(t, err)
+	assert.NotNil(t, config.RootCAs)
+	assert.Len(t, config.Certificates, 1)
+}
+
+func TestNewTLSConfig_Defaults(t *testing.T) {
+	config, err := NewTLSConfig("", "", "")
+
+	require.NoError(t, err)
+	assert.Nil(t, config.RootCAs, "the system pool is used")
+	assert.Empty(t, config.Certificates)
+}
+
+func TestNewTLSConfig_Errors(t *testing.T) {
+	certFile, keyFile := writeCertificate(t)
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+
+	_, err := NewTLSConfig(empty, "", "")
+	assert.ErrorContains(t, err, "no certificates")
+
+	_, err = NewTLSConfig("", certFile, "")
+	assert.ErrorContains(t, err, "set together")
+
+	_, err = NewTLSConfig("", keyFile, certFile)
+	assert.Error(t, err)
+}
+
+func TestNewSASLMechanism(t *testing.T) {
+	for name, expected := range map[string]string{
+		"plain":         "PLAIN",
+		"SCRAM-SHA-256": "SCRAM-SHA-256",
+		"scram-sha-512": "SCRAM-SHA-512",
+	} {
+		mechanism, err := NewSASLMechanism(name, "user", "secret")
+
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, mechanism.Name())
+	}
+
+	_, err := NewSASLMechanism("GSSAPI", "user", "secret")
+	assert.Error(t, err)
+}
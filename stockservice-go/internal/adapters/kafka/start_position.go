@@ -0,0 +1,211 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// StartMode selects where the consumer group starts reading.
+type StartMode string
+
+const (
+	// StartCommitted resumes from the group's committed offsets.
+	StartCommitted StartMode = "committed"
+	StartEarliest  StartMode = "earliest"
+	StartLatest    StartMode = "latest"
+	// StartOffsets starts the listed partitions at the given offsets.
+	StartOffsets StartMode = "offsets"
+	// StartTimestamp starts every partition at its first message at or after
+	// a point in time.
+	StartTimestamp StartMode = "timestamp"
+)
+
+type StartPosition struct {
+	Mode    StartMode
+	Offsets map[int]int64 // for StartOffsets, by partition
+	Time    time.Time     // for StartTimestamp
+}
+
+// ParseStartPosition parses "committed", "earliest", "latest",
+// "offsets:0=1200,1=1187" or "timestamp:2024-04-27T00:00:00Z". Empty means
+// committed.
+func ParseStartPosition(value string) (StartPosition, error) {
+	mode, arg, _ := strings.Cut(strings.TrimSpace(value), ":")
+	switch StartMode(strings.ToLower(mode)) {
+	case "", StartCommitted:
+		return StartPosition{Mode: StartCommitted}, nil
+	case StartEarliest:
+		return StartPosition{Mode: StartEarliest}, nil
+	case StartLatest:
+		return StartPosition{Mode: StartLatest}, nil
+	case StartTimestamp:
+		at, err := time.Parse(time.RFC3339, arg)
+		if err != nil {
+			return StartPosition{}, fmt.Errorf("invalid start timestamp %q: expected RFC 3339", arg)
+		}
+		return StartPosition{Mode: StartTimestamp, Time: at}, nil
+	case StartOffsets:
+		offsets := make(map[int]int64)
+		for _, entry := range strings.Split(arg, ",") {
+			partition, offset, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			p, perr := strconv.Atoi(partition)
+			o, oerr := strconv.ParseInt(offset, 10, 64)
+			if !ok || perr != nil || oerr != nil || p < 0 || o < 0 {
+				return StartPosition{}, fmt.Errorf("invalid start offset %q: expected partition=offset", entry)
+			}
+			offsets[p] = o
+		}
+		return StartPosition{Mode: StartOffsets, Offsets: offsets}, nil
+	default:
+		return StartPosition{}, fmt.Errorf("unknown start position %q", value)
+	}
+}
+
+// offsetClient is the part of kafka.Client used to move the group's offsets.
+type offsetClient interface {
+	Metadata(ctx context.Context, req *kafka.MetadataRequest) (*kafka.MetadataResponse, error)
+	ListOffsets(ctx context.Context, req *kafka.ListOffsetsRequest) (*kafka.ListOffsetsResponse, error)
+	OffsetCommit(ctx context.Context, req *kafka.OffsetCommitRequest) (*kafka.OffsetCommitResponse, error)
+}
+
+// seekGroup commits the offsets pos resolves to for groupID, so the group
+// starts there when it joins. Kafka only accepts such commits while the
+// group has no active members.
+func seekGroup(ctx context.Context, client offsetClient, topic, groupID string, pos StartPosition) (map[int]int64, error) {
+	offsets, err := resolveOffsets(ctx, client, topic, pos)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]kafka.OffsetCommit, 0, len(offsets))
+	for partition, offset := range offsets {
+		commits = append(commits, kafka.OffsetCommit{Partition: partition, Offset: offset})
+	}
+	sort.Slice(commits, func(i, j int) bool { return commits[i].Partition < commits[j].Partition })
+
+	resp, err := client.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      groupID,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("committing start offsets: %w", err)
+	}
+	for _, partition := range resp.Topics[topic] {
+		if partition.Error != nil {
+			return nil, fmt.Errorf("committing start offset of partition %d (is another consumer in group %s running?): %w", partition.Partition, groupID, partition.Error)
+		}
+	}
+	return offsets, nil
+}
+
+func resolveOffsets(ctx context.Context, client offsetClient, topic string, pos StartPosition) (map[int]int64, error) {
+	partitions, err := topicPartitions(ctx, client, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	if pos.Mode == StartOffsets {
+		known := make(map[int]bool, len(partitions))
+		for _, p := range partitions {
+			known[p] = true
+		}
+		for p := range pos.Offsets {
+			if !known[p] {
+				return nil, fmt.Errorf("topic %s has no partition %d", topic, p)
+			}
+		}
+		return pos.Offsets, nil
+	}
+
+	requests := make([]kafka.OffsetRequest, 0, len(partitions))
+	for _, p := range partitions {
+		switch pos.Mode {
+		case StartEarliest:
+			requests = append(requests, kafka.FirstOffsetOf(p))
+		case StartLatest:
+			requests = append(requests, kafka.LastOffsetOf(p))
+		case StartTimestamp:
+			requests = append(requests, kafka.TimeOffsetOf(p, pos.Time))
+		default:
+			return nil, fmt.Errorf("start position %q has no offsets to resolve", pos.Mode)
+		}
+	}
+	listed, err := listOffsets(ctx, client, topic, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets := make(map[int]int64, len(listed))
+	var pastEnd []kafka.OffsetRequest
+	for _, po := range listed {
+		switch pos.Mode {
+		case StartEarliest:
+			offsets[po.Partition] = po.FirstOffset
+		case StartLatest:
+			offsets[po.Partition] = po.LastOffset
+		case StartTimestamp:
+			// Kafka answers -1 when no message is that recent.
+			offsets[po.Partition] = -1
+			for offset := range po.Offsets {
+				offsets[po.Partition] = offset
+			}
+			if offsets[po.Partition] < 0 {
+				pastEnd = append(pastEnd, kafka.LastOffsetOf(po.Partition))
+			}
+		}
+	}
+
+	if len(pastEnd) > 0 {
+		latest, err := listOffsets(ctx, client, topic, pastEnd)
+		if err != nil {
+			return nil, err
+		}
+		for _, po := range latest {
+			offsets[po.Partition] = po.LastOffset
+		}
+	}
+	return offsets, nil
+}
+
+func topicPartitions(ctx context.Context, client offsetClient, topic string) ([]int, error) {
+	meta, err := client.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("fetching metadata of topic %s: %w", topic, err)
+	}
+	for _, t := range meta.Topics {
+		if t.Name != topic {
+			continue
+		}
+		if t.Error != nil {
+			return nil, fmt.Errorf("fetching metadata of topic %s: %w", topic, t.Error)
+		}
+		partitions := make([]int, 0, len(t.Partitions))
+		for _, p := range t.Partitions {
+			partitions = append(partitions, p.ID)
+		}
+		return partitions, nil
+	}
+	return nil, fmt.Errorf("topic %s not found", topic)
+}
+
+func listOffsets(ctx context.Context, client offsetClient, topic string, requests []kafka.OffsetRequest) ([]kafka.PartitionOffsets, error) {
+	resp, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: requests},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing offsets of topic %s: %w", topic, err)
+	}
+	for _, po := range resp.Topics[topic] {
+		if po.Error != nil {
+			return nil, fmt.Errorf("listing offsets of partition %d: %w", po.Partition, po.Error)
+		}
+	}
+	return resp.Topics[topic], nil
+}
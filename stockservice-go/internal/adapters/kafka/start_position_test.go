@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStartPosition(t *testing.T) {
+	at := time.Date(2024, 4, 27, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value    string
+		expected StartPosition
+	}{
+		{"", StartPosition{Mode: StartCommitted}},
+		{"committed", StartPosition{Mode: StartCommitted}},
+		{"EARLIEST", StartPosition{Mode: StartEarliest}},
+		{"latest", StartPosition{Mode: StartLatest}},
+		{"offsets:0=1200, 1=1187", StartPosition{Mode: StartOffsets, Offsets: map[int]int64{0: 1200, 1: 1187}}},
+		{"timestamp:2024-04-27T00:00:00Z", StartPosition{Mode: StartTimestamp, Time: at}},
+	}
+	for _, tt := range tests {
+		pos, err := ParseStartPosition(tt.value)
+		assert.NoError(t, err, tt.value)
+		assert.Equal(t, tt.expected, pos, tt.value)
+	}
+
+	for _, invalid := range []string{"newest", "offsets:", "offsets:0=-1", "offsets:a=1", "timestamp:yesterday"} {
+		_, err := ParseStartPosition(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+// fakeOffsetClient serves a two-partition topic whose messages have offsets
+// 10 to 99, the timestamp lookup finding a message only in partition 0.
+type fakeOffsetClient struct {
+	commits   *kafka.OffsetCommitRequest
+	commitErr error
+}
+
+func (c *fakeOffsetClient) Metadata(_ context.Context, req *kafka.MetadataRequest) (*kafka.MetadataResponse, error) {
+	return &kafka.MetadataResponse{Topics: []kafka.Topic{{
+		Name:       req.Topics[0],
+		Partitions: []kafka.Partition{{ID: 0}, {ID: 1}},
+	}}}, nil
+}
+
+func (c *fakeOffsetClient) ListOffsets(_ context.Context, req *kafka.ListOffsetsRequest) (*kafka.ListOffsetsResponse, error) {
+	resp := &kafka.ListOffsetsResponse{Topics: make(map[string][]kafka.PartitionOffsets)}
+	for topic, requests := range req.Topics {
+		for _, r := range requests {
+			po := kafka.PartitionOffsets{Partition: r.Partition, FirstOffset: -1, LastOffset: -1, Offsets: map[int64]time.Time{}}
+			switch r.Timestamp {
+			case kafka.FirstOffset:
+				po.FirstOffset = 10
+			case kafka.LastOffset:
+				po.LastOffset = 100
+			default:
+				if r.Partition == 0 {
+					po.Offsets[42] = time.UnixMilli(r.Timestamp)
+				} else {
+					po.Offsets[-1] = time.UnixMilli(-1)
+				}
+			}
+			resp.Topics[topic] = append(resp.Topics[topic], po)
+		}
+	}
+	return resp, nil
+}
+
+func (c *fakeOffsetClient) OffsetCommit(_ context.Context, req *kafka.OffsetCommitRequest) (*kafka.OffsetCommitResponse, error) {
+	c.commits = req
+	resp := &kafka.OffsetCommitResponse{Topics: make(map[string][]kafka.OffsetCommitPartition)}
+	for topic, commits := range req.Topics {
+		for _, commit := range commits {
+			resp.Topics[topic] = append(resp.Topics[topic], kafka.OffsetCommitPartition{Partition: commit.Partition, Error: c.commitErr})
+		}
+	}
+	return resp, nil
+}
+
+func TestSeekGroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		pos      StartPosition
+		expected map[int]int64
+	}{
+		{"earliest", StartPosition{Mode: StartEarliest}, map[int]int64{0: 10, 1: 10}},
+		{"latest", StartPosition{Mode: StartLatest}, map[int]int64{0: 100, 1: 100}},
+		{"offsets", StartPosition{Mode: StartOffsets, Offsets: map[int]int64{1: 55}}, map[int]int64{1: 55}},
+		{"timestamp falls back to latest", StartPosition{Mode: StartTimestamp, Time: time.Now()}, map[int]int64{0: 42, 1: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeOffsetClient{}
+
+			offsets, err := seekGroup(context.Background(), client, "prices", "group", tt.pos)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, offsets)
+			assert.Equal(t, "group", client.commits.GroupID)
+			assert.Equal(t, -1, client.commits.GenerationID)
+			assert.Len(t, client.commits.Topics["prices"], len(tt.expected))
+			for _, commit := range client.commits.Topics["prices"] {
+				assert.Equal(t, tt.expected[commit.Partition], commit.Offset)
+			}
+		})
+	}
+}
+
+func TestSeekGroup_UnknownPartition(t *testing.T) {
+	client := &fakeOffsetClient{}
+
+	_, err := seekGroup(context.Background(), client, "prices", "group", StartPosition{Mode: StartOffsets, Offsets: map[int]int64{7: 1}})
+
+	assert.ErrorContains(t, err, "no partition 7")
+	assert.Nil(t, client.commits)
+}
+
+func TestSeekGroup_CommitRejected(t *testing.T) {
+	client := &fakeOffsetClient{commitErr: errors.New("rebalance in progress")}
+
+	_, err := seekGroup(context.Background(), client, "prices", "group", StartPosition{Mode: StartLatest})
+
+	assert.ErrorContains(t, err, "rebalance in progress")
+}
@@ -0,0 +1,194 @@
+package notifier
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+)
+
+// SlowConsumerPolicy decides what happens when a client's outbound queue is full.
+type SlowConsumerPolicy string
+
+const (
+	// DropOldest discards the oldest queued message to make room for the new one.
+	DropOldest SlowConsumerPolicy = "drop_oldest"
+	// ConflateLatest replaces queued price updates for the same stock with the
+	// newest one, falling back to DropOldest when there is nothing to conflate.
+	ConflateLatest SlowConsumerPolicy = "conflate"
+	// Disconnect closes the connection of a client that cannot keep up.
+	Disconnect SlowConsumerPolicy = "disconnect"
+)
+
+const defaultQueueSize = 256
+
+// writeWait bounds each write, so a peer that stops reading is disconnected
+// instead of stalling its writer until the heartbeat gives up on it.
+const writeWait = 10 * time.Second
+
+func ParseSlowConsumerPolicy(value string) (SlowConsumerPolicy, bool) {
+	switch policy := SlowConsumerPolicy(value); policy {
+	case DropOldest, ConflateLatest, Disconnect:
+		return policy, true
+	default:
+		return "", false
+	}
+}
+
+type outboundMessage struct {
+	messageType int
+	data        []byte
+	// stock is set for price updates so they can be conflated; control
+	// messages leave it empty.
+	stock    domain.Stock
+	sequence int64
+	// kind is the "type" of a broadcast control message, such as a candle
+	// or status update.
+	kind string
+}
+
+// write sends msg, labelled for connections that name their messages when the
+// notifier knows what it is. Other messages are left for the connection to
+// inspect.
+func (c *client) write(msg outboundMessage) error {
+	if labeled, ok := c.ws.(ports.LabeledWriter); ok && (msg.stock != "" || msg.kind != "") {
+		label := ports.MessageLabel{Type: msg.kind, Stock: msg.stock, Sequence: msg.sequence}
+		return labeled.WriteLabeled(msg.messageType, msg.data, label)
+	}
+	return c.ws.WriteMessage(msg.messageType, msg.data)
+}
+
+// client owns the only goroutine allowed to write to its connection. Producers
+// enqueue messages and never block on the network.
+type client struct {
+	ws          ports.WebSocketConn
+	id          string
+	connectedAt time.Time
+	format      domain.WireFormat
+	policy      SlowConsumerPolicy
+	size        int
+
+	mu     sync.Mutex
+	queue  []outboundMessage
+	closed bool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+func newClient(ws ports.WebSocketConn, size int, policy SlowConsumerPolicy) *client {
+	return &client{
+		ws:          ws,
+		connectedAt: time.Now().UTC(),
+		format:      domain.WireJSON,
+		policy:      policy,
+		size:        size,
+		queue:       make([]outboundMessage, 0, size),
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+}
+
+func (c *client) queueDepth() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.queue)
+}
+
+// enqueue adds msg to the queue and reports false when the client must be
+// disconnected because it is too slow.
+func (c *client) enqueue(msg outboundMessage) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return true
+	}
+
+	if len(c.queue) >= c.size {
+		switch c.policy {
+		case Disconnect:
+			return false
+		case ConflateLatest:
+			if !c.conflate(msg.stock) {
+				c.queue = c.queue[1:]
+			}
+		default:
+			c.queue = c.queue[1:]
+		}
+	}
+	c.queue = append(c.queue, msg)
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// conflate removes queued price updates for stock and reports whether any
+// were removed.
+func (c *client) conflate(stock domain.Stock) bool {
+	if stock == "" {
+		return false
+	}
+	kept := c.queue[:0]
+	for _, queued := range c.queue {
+		if queued.stock != stock {
+			kept = append(kept, queued)
+		}
+	}
+	removed := len(kept) < len(c.queue)
+	c.queue = kept
+	return removed
+}
+
+func (c *client) drain() []outboundMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending := c.queue
+	c.queue = make([]outboundMessage, 0, c.size)
+	return pending
+}
+
+func (c *client) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.closed {
+		c.closed = true
+		c.queue = nil
+		close(c.done)
+	}
+}
+
+// writeLoop delivers queued messages until the client is closed or a write
+// fails, in which case onError is called once.
+func (c *client) writeLoop(onError func(err error)) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-c.wake:
+		}
+
+		for _, msg := range c.drain() {
+			select {
+			case <-c.done:
+				return
+			default:
+			}
+			if err := c.ws.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+				onError(err)
+				return
+			}
+			if err := c.write(msg); err != nil {
+				onError(err)
+				return
+			}
+		}
+	}
+}
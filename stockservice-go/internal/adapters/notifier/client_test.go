@@ -0,0 +1,100 @@
+package notifier
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func priceMessage(stock domain.Stock, data string) outboundMessage {
+	return outboundMessage{messageType: websocket.TextMessage, data: []byte(data), stock: stock}
+}
+
+func TestClient_Enqueue_DropOldest(t *testing.T) {
+	c := newClient(nil, 2, DropOldest)
+
+	assert.True(t, c.enqueue(priceMessage(aStock, "1")))
+	assert.True(t, c.enqueue(priceMessage(aStock, "2")))
+	assert.True(t, c.enqueue(priceMessage(aStock, "3")))
+
+	assert.Equal(t, []outboundMessage{priceMessage(aStock, "2"), priceMessage(aStock, "3")}, c.drain())
+}
+
+func TestClient_Enqueue_ConflateLatest(t *testing.T) {
+	c := newClient(nil, 2, ConflateLatest)
+	control := outboundMessage{messageType: websocket.TextMessage, data: []byte("ack")}
+
+	assert.True(t, c.enqueue(control))
+	assert.True(t, c.enqueue(priceMessage(aStock, "1")))
+	assert.True(t, c.enqueue(priceMessage(aStock, "2")))
+
+	assert.Equal(t, []outboundMessage{control, priceMessage(aStock, "2")}, c.drain())
+}
+
+func TestClient_Enqueue_ConflateLatestFallsBackToDropOldest(t *testing.T) {
+	c := newClient(nil, 2, ConflateLatest)
+
+	assert.True(t, c.enqueue(priceMessage("ETH-USD", "1")))
+	assert.True(t, c.enqueue(priceMessage("SOL-USD", "2")))
+	assert.True(t, c.enqueue(priceMessage(aStock, "3")))
+
+	assert.Equal(t, []outboundMessage{priceMessage("SOL-USD", "2"), priceMessage(aStock, "3")}, c.drain())
+}
+
+func TestClient_Enqueue_Disconnect(t *testing.T) {
+	c := newClient(nil, 1, Disconnect)
+
+	assert.True(t, c.enqueue(priceMessage(aStock, "1")))
+	assert.False(t, c.enqueue(priceMessage(aStock, "2")))
+}
+
+func TestClient_Enqueue_AfterClose(t *testing.T) {
+	c := newClient(nil, 1, Disconnect)
+	c.close()
+
+	assert.True(t, c.enqueue(priceMessage(aStock, "1")))
+	assert.Empty(t, c.drain())
+}
+
+func TestClient_WriteLoop_SetsWriteDeadline(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	conn := mocks.NewMockWebSocketConn(ctrl)
+	c := newClient(conn, 1, DropOldest)
+	defer c.close()
+
+	timeout := errors.New("i/o timeout")
+	gomock.InOrder(
+		conn.EXPECT().SetWriteDeadline(gomock.Cond(func(x any) bool {
+			deadline := x.(time.Time)
+			return deadline.After(time.Now()) && !deadline.After(time.Now().Add(writeWait))
+		})).Return(nil),
+		conn.EXPECT().WriteMessage(websocket.TextMessage, []byte("1")).Return(timeout),
+	)
+
+	failed := make(chan error, 1)
+	go c.writeLoop(func(err error) { failed <- err })
+	assert.True(t, c.enqueue(priceMessage(aStock, "1")))
+
+	select {
+	case err := <-failed:
+		assert.Equal(t, timeout, err)
+	case <-time.After(time.Second):
+		t.Fatal("a failed write must end the writer")
+	}
+}
+
+func TestParseSlowConsumerPolicy(t *testing.T) {
+	policy, ok := ParseSlowConsumerPolicy("conflate")
+	assert.True(t, ok)
+	assert.Equal(t, ConflateLatest, policy)
+
+	_, ok = ParseSlowConsumerPolicy("unknown")
+	assert.False(t, ok)
+}
@@ -1,9 +1,13 @@
 package notifier
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
@@ -11,35 +15,160 @@ import (
 )
 
 type Notifier struct {
-	conns         sync.Map // key: ports.WebSocketConn, value: struct{}
-	subscriptions sync.Map // key: domain.Stock, value: *sync.Map (key: ports.WebSocketConn, value: struct{})
+	conns         sync.Map // key: ports.WebSocketConn, value: *client
+	subscriptions sync.Map // key: domain.Stock, value: *sync.Map (key: ports.WebSocketConn, value: *subscription)
+	candleSubs    sync.Map // key: candleTopic, value: *sync.Map (key: ports.WebSocketConn, value: struct{})
 	logger        ports.Logger
+	queueSize     int
+	policy        SlowConsumerPolicy
+	lastClientID  atomic.Int64
 }
 
-func NewNotifier(logger ports.Logger) *Notifier {
-	return &Notifier{
-		logger: logger,
+type candleTopic struct {
+	stock    domain.Stock
+	interval domain.CandleInterval
+}
+
+type Option func(*Notifier)
+
+// WithQueueSize bounds the number of messages buffered per client.
+func WithQueueSize(size int) Option {
+	return func(n *Notifier) {
+		if size > 0 {
+			n.queueSize = size
+		}
+	}
+}
+
+// WithSlowConsumerPolicy sets what happens when a client's queue is full.
+func WithSlowConsumerPolicy(policy SlowConsumerPolicy) Option {
+	return func(n *Notifier) {
+		n.policy = policy
+	}
+}
+
+func NewNotifier(logger ports.Logger, opts ...Option) *Notifier {
+	n := &Notifier{
+		logger:    logger,
+		queueSize: defaultQueueSize,
+		policy:    DropOldest,
 	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
 }
 
 func (n *Notifier) AddClient(ws ports.WebSocketConn) {
-	n.conns.Store(ws, struct{}{})
+	n.clientFor(ws)
+}
+
+// clientFor returns the client registered for ws, registering it and starting
+// its writer if needed.
+func (n *Notifier) clientFor(ws ports.WebSocketConn) *client {
+	if existing, ok := n.conns.Load(ws); ok {
+		return existing.(*client)
+	}
+
+	c := newClient(ws, n.queueSize, n.policy)
+	c.id = fmt.Sprintf("client-%d", n.lastClientID.Add(1))
+	c.format = domain.ParseWireFormat(ws.Subprotocol())
+	actual, loaded := n.conns.LoadOrStore(ws, c)
+	if loaded {
+		return actual.(*client)
+	}
+
+	go c.writeLoop(func(err error) {
+		n.logger.Errorf("Error sending message to client %v: %v", ws.RemoteAddr(), err)
+		n.disconnect(ws)
+	})
+	return c
 }
 
 func (n *Notifier) RemoveClient(ws ports.WebSocketConn) {
-	n.conns.Delete(ws)
+	if c, ok := n.conns.LoadAndDelete(ws); ok {
+		c.(*client).close()
+	}
 
 	n.subscriptions.Range(func(key, value interface{}) bool {
+		clients := value.(*sync.Map)
+		if sub, ok := clients.LoadAndDelete(ws); ok {
+			sub.(*subscription).stop()
+		}
+		return true
+	})
+
+	n.candleSubs.Range(func(key, value interface{}) bool {
 		clients := value.(*sync.Map)
 		clients.Delete(ws)
 		return true
 	})
 }
 
-func (n *Notifier) Subscribe(ws ports.WebSocketConn, stock domain.Stock) error {
+// disconnect drops a client the notifier can no longer serve and closes its
+// connection so the handler's read loop ends as well.
+func (n *Notifier) disconnect(ws ports.WebSocketConn) {
+	n.RemoveClient(ws)
+	if err := ws.Close(); err != nil {
+		n.logger.Errorf("Error closing WebSocket: %v", err)
+	}
+}
+
+// maxCloseReasonLen is the longest reason a close frame can carry.
+const maxCloseReasonLen = 123
+
+// closeWait bounds the close frame write when ctx has no deadline.
+const closeWait = 5 * time.Second
+
+// CloseAll sends every connection a 1001 (going away) close frame carrying
+// reason, then closes it. It returns once every connection is closed or ctx is
+// done, whichever comes first.
+func (n *Notifier) CloseAll(ctx context.Context, reason string) {
+	if len(reason) > maxCloseReasonLen {
+		reason = strings.ToValidUTF8(reason[:maxCloseReasonLen], "")
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(closeWait)
+	}
+	frame := websocket.FormatCloseMessage(websocket.CloseGoingAway, reason)
+
+	conns := n.GetConnections()
+	var wg sync.WaitGroup
+	for ws := range conns {
+		wg.Add(1)
+		go func(ws ports.WebSocketConn) {
+			defer wg.Done()
+			if err := ws.WriteControl(websocket.CloseMessage, frame, deadline); err != nil {
+				n.logger.Errorf("Error sending close frame to %v: %v", ws.RemoteAddr(), err)
+			}
+			n.disconnect(ws)
+		}(ws)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		n.logger.Infof("Closed %d client connections", len(conns))
+	case <-ctx.Done():
+		n.logger.Errorf("Gave up draining client connections: %v", ctx.Err())
+	}
+}
+
+func (n *Notifier) Subscribe(ws ports.WebSocketConn, stock domain.Stock, opts domain.SubscriptionOptions) error {
+	c := n.clientFor(ws)
 	clientsInterface, _ := n.subscriptions.LoadOrStore(stock, &sync.Map{})
 	clients := clientsInterface.(*sync.Map)
-	clients.Store(ws, struct{}{})
+	sub := newSubscription(opts, c.format, func(msg outboundMessage) {
+		_ = n.enqueue(ws, msg)
+	})
+	if previous, loaded := clients.Swap(ws, sub); loaded {
+		previous.(*subscription).stop()
+	}
 	n.logger.Infof("Client %v subscribed to %v", ws.RemoteAddr(), stock)
 	return nil
 }
@@ -48,45 +177,182 @@ func (n *Notifier) Unsubscribe(ws ports.WebSocketConn, stock domain.Stock) error
 	clientsInterface, ok := n.subscriptions.Load(stock)
 	if ok {
 		clients := clientsInterface.(*sync.Map)
-		clients.Delete(ws)
+		if sub, loaded := clients.LoadAndDelete(ws); loaded {
+			sub.(*subscription).stop()
+		}
 		n.logger.Infof("Client %v unsubscribed from %v", ws.RemoteAddr(), stock)
 	}
 	return nil
 }
 
-func (n *Notifier) Broadcast(event *domain.PriceEvent) error {
-	if event == nil {
-		return fmt.Errorf("received a nil PriceEvent")
+func (n *Notifier) SubscribeCandles(ws ports.WebSocketConn, stock domain.Stock, interval domain.CandleInterval) error {
+	n.clientFor(ws)
+	clientsInterface, _ := n.candleSubs.LoadOrStore(candleTopic{stock: stock, interval: interval}, &sync.Map{})
+	clients := clientsInterface.(*sync.Map)
+	clients.Store(ws, struct{}{})
+	n.logger.Infof("Client %v subscribed to %v %v candles", ws.RemoteAddr(), stock, interval)
+	return nil
+}
+
+func (n *Notifier) UnsubscribeCandles(ws ports.WebSocketConn, stock domain.Stock, interval domain.CandleInterval) error {
+	clientsInterface, ok := n.candleSubs.Load(candleTopic{stock: stock, interval: interval})
+	if ok {
+		clients := clientsInterface.(*sync.Map)
+		clients.Delete(ws)
+		n.logger.Infof("Client %v unsubscribed from %v %v candles", ws.RemoteAddr(), stock, interval)
 	}
+	return nil
+}
 
-	clientsInterface, ok := n.subscriptions.Load(event.ProductID)
+// BroadcastCandle sends a candle update, or a close when candle.Closed is set,
+// to the clients subscribed to its stock and interval.
+func (n *Notifier) BroadcastCandle(candle *domain.Candle) error {
+	if candle == nil {
+		return fmt.Errorf("received a nil Candle")
+	}
+
+	clientsInterface, ok := n.candleSubs.Load(candleTopic{stock: candle.Stock, interval: candle.Interval})
 	if !ok {
 		return nil
 	}
 
-	clients := clientsInterface.(*sync.Map)
-	msg, err := json.Marshal(event)
+	message := domain.CandleMessage{Type: domain.CandleUpdateType, Candle: candle}
+	if candle.Closed {
+		message.Type = domain.CandleClosedType
+	}
+	msg, err := json.Marshal(message)
 	if err != nil {
-		n.logger.Errorf("Error marshalling price event: %v", err)
+		n.logger.Errorf("Error marshalling candle: %v", err)
 		return nil
 	}
 
+	clients := clientsInterface.(*sync.Map)
 	clients.Range(func(key, _ interface{}) bool {
 		ws := key.(ports.WebSocketConn)
-		if err := ws.WriteMessage(websocket.TextMessage, msg); err != nil {
-			n.logger.Errorf("Error sending message to client %v: %v", ws.RemoteAddr(), err)
-			clients.Delete(ws)
-			n.conns.Delete(ws)
-			if err := ws.Close(); err != nil {
-				n.logger.Errorf("Error closing WebSocket: %v", err)
+		_ = n.enqueue(ws, outboundMessage{messageType: websocket.TextMessage, data: msg, kind: message.Type})
+		return true
+	})
+	return nil
+}
+
+func (n *Notifier) BroadcastStatus(status *domain.StatusMessage) error {
+	if status == nil {
+		return fmt.Errorf("received a nil StatusMessage")
+	}
+
+	msg, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("error marshalling status: %w", err)
+	}
+
+	var recipients map[ports.WebSocketConn]struct{}
+	if status.Stock == "" {
+		recipients = n.GetConnections()
+	} else {
+		recipients = n.GetSubscriptions(status.Stock)
+	}
+	for ws := range recipients {
+		_ = n.enqueue(ws, outboundMessage{messageType: websocket.TextMessage, data: msg, kind: status.Type})
+	}
+	return nil
+}
+
+func (n *Notifier) Broadcast(event *domain.PriceEvent) error {
+	if event == nil {
+		return fmt.Errorf("received a nil PriceEvent")
+	}
+
+	clientsInterface, ok := n.subscriptions.Load(event.ProductID)
+	if !ok {
+		return nil
+	}
+
+	clients := clientsInterface.(*sync.Map)
+	// Each distinct format and projection is encoded once per event, however
+	// many clients share it.
+	encoded := make(map[encodingKey]outboundMessage)
+
+	clients.Range(func(_, value interface{}) bool {
+		sub := value.(*subscription)
+
+		msg, ok := encoded[sub.encoding]
+		if !ok {
+			data, messageType, err := encodeEvent(event, sub.fields, sub.encoding.format)
+			if err != nil {
+				n.logger.Errorf("Error marshalling price event: %v", err)
+				return true
 			}
+			msg = outboundMessage{messageType: messageType, data: data, stock: event.ProductID, sequence: event.Sequence}
+			encoded[sub.encoding] = msg
 		}
+
+		sub.publish(msg)
 		return true
 	})
 
 	return nil
 }
 
+func (n *Notifier) SendEvent(ws ports.WebSocketConn, event *domain.PriceEvent) error {
+	if event == nil {
+		return fmt.Errorf("received a nil PriceEvent")
+	}
+
+	c, ok := n.conns.Load(ws)
+	if !ok {
+		return fmt.Errorf("client %v is not connected", ws.RemoteAddr())
+	}
+
+	var fields []string
+	if sub, ok := n.subscriptionOf(ws, event.ProductID); ok {
+		fields = sub.fields
+	}
+
+	data, messageType, err := encodeEvent(event, fields, c.(*client).format)
+	if err != nil {
+		return fmt.Errorf("error marshalling price event: %w", err)
+	}
+
+	return n.enqueue(ws, outboundMessage{messageType: messageType, data: data, stock: event.ProductID, sequence: event.Sequence})
+}
+
+func (n *Notifier) subscriptionOf(ws ports.WebSocketConn, stock domain.Stock) (*subscription, bool) {
+	clientsInterface, ok := n.subscriptions.Load(stock)
+	if !ok {
+		return nil, false
+	}
+	sub, ok := clientsInterface.(*sync.Map).Load(ws)
+	if !ok {
+		return nil, false
+	}
+	return sub.(*subscription), true
+}
+
+// Send queues a JSON control message, such as an error, on the client's writer.
+// Control messages stay JSON text frames whatever the negotiated wire format.
+func (n *Notifier) Send(ws ports.WebSocketConn, message interface{}) error {
+	msg, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("error marshalling message: %w", err)
+	}
+
+	return n.enqueue(ws, outboundMessage{messageType: websocket.TextMessage, data: msg})
+}
+
+func (n *Notifier) enqueue(ws ports.WebSocketConn, msg outboundMessage) error {
+	c, ok := n.conns.Load(ws)
+	if !ok {
+		return fmt.Errorf("client %v is not connected", ws.RemoteAddr())
+	}
+
+	if !c.(*client).enqueue(msg) {
+		n.logger.Errorf("Client %v is too slow, disconnecting", ws.RemoteAddr())
+		n.disconnect(ws)
+		return fmt.Errorf("client %v disconnected: outbound queue full", ws.RemoteAddr())
+	}
+	return nil
+}
+
 func (n *Notifier) GetConnections() map[ports.WebSocketConn]struct{} {
 	connsCopy := make(map[ports.WebSocketConn]struct{})
 	n.conns.Range(func(key, value interface{}) bool {
@@ -1,11 +1,15 @@
 package notifier
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
@@ -25,6 +29,8 @@ func setup(t *testing.T) *testDependencies {
 	ctrl := gomock.NewController(t)
 	stubLogger := &mocks.StubLogger{}
 	mockConn := mocks.NewMockWebSocketConn(ctrl)
+	mockConn.EXPECT().Subprotocol().Return("").AnyTimes()
+	mockConn.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
 	notifier := NewNotifier(stubLogger)
 	return &testDependencies{
 		ctrl:       ctrl,
@@ -58,7 +64,7 @@ func TestNotifier_Subscribe(t *testing.T) {
 	defer deps.ctrl.Finish()
 
 	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
-	err := deps.notifier.Subscribe(deps.mockConn, aStock)
+	err := deps.notifier.Subscribe(deps.mockConn, aStock, domain.SubscriptionOptions{})
 
 	assert.NoError(t, err)
 	assert.Contains(t, deps.notifier.GetSubscriptions(aStock), deps.mockConn)
@@ -69,7 +75,7 @@ func TestNotifier_Unsubscribe(t *testing.T) {
 	defer deps.ctrl.Finish()
 
 	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
-	_ = deps.notifier.Subscribe(deps.mockConn, aStock)
+	_ = deps.notifier.Subscribe(deps.mockConn, aStock, domain.SubscriptionOptions{})
 
 	err := deps.notifier.Unsubscribe(deps.mockConn, aStock)
 
@@ -82,7 +88,7 @@ func TestNotifier_Broadcast(t *testing.T) {
 	defer deps.ctrl.Finish()
 
 	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
-	_ = deps.notifier.Subscribe(deps.mockConn, aStock)
+	_ = deps.notifier.Subscribe(deps.mockConn, aStock, domain.SubscriptionOptions{})
 
 	event := &domain.PriceEvent{
 		ProductID: aStock,
@@ -96,6 +102,7 @@ func TestNotifier_Broadcast(t *testing.T) {
 	err = deps.notifier.Broadcast(event)
 
 	assert.NoError(t, err)
+	assert.Eventually(t, deps.ctrl.Satisfied, time.Second, time.Millisecond)
 }
 
 func TestNotifier_Broadcast_WriteMessageError(t *testing.T) {
@@ -104,7 +111,7 @@ func TestNotifier_Broadcast_WriteMessageError(t *testing.T) {
 
 	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
 	deps.mockConn.EXPECT().Close().Return(nil)
-	_ = deps.notifier.Subscribe(deps.mockConn, aStock)
+	_ = deps.notifier.Subscribe(deps.mockConn, aStock, domain.SubscriptionOptions{})
 
 	event := &domain.PriceEvent{
 		ProductID: aStock,
@@ -119,5 +126,301 @@ func TestNotifier_Broadcast_WriteMessageError(t *testing.T) {
 	err = deps.notifier.Broadcast(event)
 
 	assert.NoError(t, err)
-	assert.NotContains(t, deps.notifier.GetConnections(), deps.mockConn)
+	assert.Eventually(t, func() bool {
+		_, connected := deps.notifier.GetConnections()[deps.mockConn]
+		return !connected
+	}, time.Second, time.Millisecond)
+	assert.Eventually(t, deps.ctrl.Satisfied, time.Second, time.Millisecond)
+	assert.NotContains(t, deps.notifier.GetSubscriptions(aStock), deps.mockConn)
+}
+
+func TestNotifier_SendEvent(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.notifier.AddClient(deps.mockConn)
+
+	event := &domain.PriceEvent{
+		ProductID: aStock,
+		Price:     50000.00,
+		Snapshot:  true,
+	}
+
+	msg, err := json.Marshal(event)
+	assert.NoError(t, err)
+	deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, msg).Return(nil).Times(1)
+
+	err = deps.notifier.SendEvent(deps.mockConn, event)
+
+	assert.NoError(t, err)
+	assert.Eventually(t, deps.ctrl.Satisfied, time.Second, time.Millisecond)
+}
+
+func TestNotifier_Send(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.notifier.AddClient(deps.mockConn)
+
+	message := domain.ErrorMessage{Type: "error", Message: "Unknown action"}
+	msg, err := json.Marshal(message)
+	assert.NoError(t, err)
+	deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, msg).Return(nil).Times(1)
+
+	err = deps.notifier.Send(deps.mockConn, message)
+
+	assert.NoError(t, err)
+	assert.Eventually(t, deps.ctrl.Satisfied, time.Second, time.Millisecond)
+}
+
+func TestNotifier_Send_UnknownClient(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+
+	err := deps.notifier.Send(deps.mockConn, domain.ErrorMessage{Type: "error"})
+
+	assert.Error(t, err)
+}
+
+func TestNotifier_Broadcast_DisconnectsSlowConsumer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn := mocks.NewMockWebSocketConn(ctrl)
+	notifier := NewNotifier(&mocks.StubLogger{}, WithQueueSize(1), WithSlowConsumerPolicy(Disconnect))
+
+	release := make(chan struct{})
+	conn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	conn.EXPECT().Subprotocol().Return("").AnyTimes()
+	conn.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
+	conn.EXPECT().WriteMessage(websocket.TextMessage, gomock.Any()).DoAndReturn(func(int, []byte) error {
+		<-release
+		return nil
+	}).AnyTimes()
+	conn.EXPECT().Close().DoAndReturn(func() error {
+		close(release)
+		return nil
+	})
+	_ = notifier.Subscribe(conn, aStock, domain.SubscriptionOptions{})
+
+	event := &domain.PriceEvent{ProductID: aStock, Price: 50000.00}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, notifier.Broadcast(event))
+	}
+
+	assert.NotContains(t, notifier.GetConnections(), conn)
+	assert.NotContains(t, notifier.GetSubscriptions(aStock), conn)
+}
+
+func TestNotifier_Broadcast_ProjectsFieldsOncePerProjection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	notifier := NewNotifier(&mocks.StubLogger{})
+	fullConn := mocks.NewMockWebSocketConn(ctrl)
+	mobileConn := mocks.NewMockWebSocketConn(ctrl)
+	otherMobileConn := mocks.NewMockWebSocketConn(ctrl)
+	for _, conn := range []*mocks.MockWebSocketConn{fullConn, mobileConn, otherMobileConn} {
+		conn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+		conn.EXPECT().Subprotocol().Return("").AnyTimes()
+		conn.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
+	}
+
+	fields, err := domain.ParseFields([]string{"price", "best_bid", "best_ask"})
+	assert.NoError(t, err)
+	_ = notifier.Subscribe(fullConn, aStock, domain.SubscriptionOptions{})
+	_ = notifier.Subscribe(mobileConn, aStock, domain.SubscriptionOptions{Fields: fields})
+	_ = notifier.Subscribe(otherMobileConn, aStock, domain.SubscriptionOptions{Fields: fields})
+
+	event := &domain.PriceEvent{
+		ProductID: aStock,
+		Price:     50000.00,
+		BestBid:   49999.00,
+		BestAsk:   50001.00,
+		Volume24H: 1234.5,
+	}
+	fullMsg, err := json.Marshal(event)
+	assert.NoError(t, err)
+
+	var mu sync.Mutex
+	var projected [][]byte
+	fullConn.EXPECT().WriteMessage(websocket.TextMessage, fullMsg).Return(nil)
+	recordWrite := func(_ int, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		projected = append(projected, data)
+		return nil
+	}
+	mobileConn.EXPECT().WriteMessage(websocket.TextMessage, gomock.Any()).DoAndReturn(recordWrite)
+	otherMobileConn.EXPECT().WriteMessage(websocket.TextMessage, gomock.Any()).DoAndReturn(recordWrite)
+
+	err = notifier.Broadcast(event)
+
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(projected) == 2
+	}, time.Second, time.Millisecond)
+	assert.Eventually(t, ctrl.Satisfied, time.Second, time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.JSONEq(t, `{"ProductID":"BTC-USD","Price":50000,"BestBid":49999,"BestAsk":50001}`, string(projected[0]))
+	assert.Same(t, &projected[0][0], &projected[1][0], "projection should be encoded once")
+}
+
+func TestNotifier_BroadcastCandle(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	_ = deps.notifier.SubscribeCandles(deps.mockConn, aStock, domain.Interval1m)
+
+	candle := &domain.Candle{Stock: aStock, Interval: domain.Interval1m, Open: 1, High: 2, Low: 1, Close: 2, Closed: true}
+	msg, err := json.Marshal(domain.CandleMessage{Type: domain.CandleClosedType, Candle: candle})
+	assert.NoError(t, err)
+	deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, msg).Return(nil).Times(1)
+
+	// Other intervals of the same stock are not delivered.
+	assert.NoError(t, deps.notifier.BroadcastCandle(&domain.Candle{Stock: aStock, Interval: domain.Interval5m}))
+	assert.NoError(t, deps.notifier.BroadcastCandle(candle))
+
+	assert.Eventually(t, deps.ctrl.Satisfied, time.Second, time.Millisecond)
+}
+
+func TestNotifier_UnsubscribeCandles(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	_ = deps.notifier.SubscribeCandles(deps.mockConn, aStock, domain.Interval1m)
+	_ = deps.notifier.UnsubscribeCandles(deps.mockConn, aStock, domain.Interval1m)
+
+	deps.mockConn.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).Times(0)
+
+	assert.NoError(t, deps.notifier.BroadcastCandle(&domain.Candle{Stock: aStock, Interval: domain.Interval1m}))
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestNotifier_CloseAll(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	other := mocks.NewMockWebSocketConn(deps.ctrl)
+	other.EXPECT().Subprotocol().Return("").AnyTimes()
+	other.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
+	frame := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, conn := range []*mocks.MockWebSocketConn{deps.mockConn, other} {
+		conn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+		conn.EXPECT().WriteControl(websocket.CloseMessage, frame, gomock.Any()).Return(nil)
+		conn.EXPECT().Close().Return(nil)
+		deps.notifier.AddClient(conn)
+	}
+	_ = deps.notifier.Subscribe(deps.mockConn, aStock, domain.SubscriptionOptions{})
+
+	deps.notifier.CloseAll(context.Background(), "server shutting down")
+
+	assert.Empty(t, deps.notifier.GetConnections())
+	assert.Empty(t, deps.notifier.GetSubscriptions(aStock))
+}
+
+func TestNotifier_CloseAllGivesUpAtDeadline(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	release := make(chan struct{})
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	deps.mockConn.EXPECT().WriteControl(websocket.CloseMessage, gomock.Any(), gomock.Any()).DoAndReturn(func(int, []byte, time.Time) error {
+		<-release
+		return nil
+	})
+	deps.mockConn.EXPECT().Close().Return(nil)
+	deps.notifier.AddClient(deps.mockConn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	deps.notifier.CloseAll(ctx, "server shutting down")
+
+	assert.Less(t, time.Since(start), time.Second)
+
+	close(release)
+	assert.Eventually(t, deps.ctrl.Satisfied, time.Second, time.Millisecond)
+}
+
+func TestNotifier_BroadcastStatus(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	unsubscribed := mocks.NewMockWebSocketConn(deps.ctrl)
+	unsubscribed.EXPECT().Subprotocol().Return("").AnyTimes()
+	unsubscribed.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	deps.notifier.AddClient(unsubscribed)
+	_ = deps.notifier.Subscribe(deps.mockConn, aStock, domain.SubscriptionOptions{})
+
+	gap := &domain.StatusMessage{Type: domain.StatusType, Status: domain.StatusSequenceGap, Stock: aStock, Message: "gap"}
+	gapJSON, _ := json.Marshal(gap)
+	global := &domain.StatusMessage{Type: domain.StatusType, Status: domain.StatusSequenceRegression, Message: "all"}
+	globalJSON, _ := json.Marshal(global)
+	gomock.InOrder(
+		deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, gapJSON).Return(nil),
+		deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, globalJSON).Return(nil),
+	)
+	unsubscribed.EXPECT().WriteMessage(websocket.TextMessage, globalJSON).Return(nil)
+
+	assert.NoError(t, deps.notifier.BroadcastStatus(gap))
+	assert.NoError(t, deps.notifier.BroadcastStatus(global))
+
+	assert.Eventually(t, deps.ctrl.Satisfied, time.Second, time.Millisecond)
+}
+
+// labeledConn records the labels the notifier passes to connections that
+// name their messages.
+type labeledConn struct {
+	*mocks.MockWebSocketConn
+
+	mu     sync.Mutex
+	labels []ports.MessageLabel
+}
+
+func (c *labeledConn) WriteLabeled(_ int, _ []byte, label ports.MessageLabel) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.labels = append(c.labels, label)
+	return nil
+}
+
+func (c *labeledConn) written() []ports.MessageLabel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ports.MessageLabel(nil), c.labels...)
+}
+
+func TestNotifier_LabelsMessagesForLabeledWriters(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	conn := &labeledConn{MockWebSocketConn: deps.mockConn}
+	_ = deps.notifier.Subscribe(conn, aStock, domain.SubscriptionOptions{})
+
+	// A message sent to one client is left for the connection to inspect.
+	deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, gomock.Any()).Return(nil)
+
+	event := &domain.PriceEvent{ProductID: aStock, Sequence: 42, Price: 1}
+	assert.NoError(t, deps.notifier.Broadcast(event))
+	assert.NoError(t, deps.notifier.BroadcastStatus(&domain.StatusMessage{Type: domain.StatusType, Status: domain.StatusDegraded}))
+	assert.NoError(t, deps.notifier.Send(conn, domain.AckMessage{Type: "ack"}))
+
+	expected := []ports.MessageLabel{
+		{Stock: aStock, Sequence: 42},
+		{Type: domain.StatusType},
+	}
+	assert.Eventually(t, func() bool {
+		return deps.ctrl.Satisfied() && assert.ObjectsAreEqual(expected, conn.written())
+	}, time.Second, time.Millisecond)
 }
@@ -0,0 +1,152 @@
+package notifier
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+	"github.com/gorilla/websocket"
+)
+
+// Clients returns every connected client, oldest connection first.
+func (n *Notifier) Clients() []domain.ClientInfo {
+	tickers := make(map[ports.WebSocketConn][]domain.Stock)
+	n.subscriptions.Range(func(key, value interface{}) bool {
+		stock := key.(domain.Stock)
+		value.(*sync.Map).Range(func(ws, _ interface{}) bool {
+			tickers[ws.(ports.WebSocketConn)] = append(tickers[ws.(ports.WebSocketConn)], stock)
+			return true
+		})
+		return true
+	})
+
+	candles := make(map[ports.WebSocketConn][]domain.CandleSubscription)
+	n.candleSubs.Range(func(key, value interface{}) bool {
+		topic := key.(candleTopic)
+		value.(*sync.Map).Range(func(ws, _ interface{}) bool {
+			sub := domain.CandleSubscription{Stock: topic.stock, Interval: topic.interval}
+			candles[ws.(ports.WebSocketConn)] = append(candles[ws.(ports.WebSocketConn)], sub)
+			return true
+		})
+		return true
+	})
+
+	var clients []domain.ClientInfo
+	n.conns.Range(func(key, value interface{}) bool {
+		ws, c := key.(ports.WebSocketConn), value.(*client)
+		info := domain.ClientInfo{
+			ID:                  c.id,
+			ConnectedAt:         c.connectedAt,
+			Subscriptions:       tickers[ws],
+			CandleSubscriptions: candles[ws],
+			QueueDepth:          c.queueDepth(),
+		}
+		if addr := ws.RemoteAddr(); addr != nil {
+			info.RemoteAddr = addr.String()
+		}
+		if info.Subscriptions == nil {
+			info.Subscriptions = []domain.Stock{}
+		}
+		sort.Slice(info.Subscriptions, func(i, j int) bool { return info.Subscriptions[i] < info.Subscriptions[j] })
+		sort.Slice(info.CandleSubscriptions, func(i, j int) bool {
+			a, b := info.CandleSubscriptions[i], info.CandleSubscriptions[j]
+			if a.Stock != b.Stock {
+				return a.Stock < b.Stock
+			}
+			return a.Interval.Duration() < b.Interval.Duration()
+		})
+		clients = append(clients, info)
+		return true
+	})
+
+	sort.Slice(clients, func(i, j int) bool {
+		if !clients[i].ConnectedAt.Equal(clients[j].ConnectedAt) {
+			return clients[i].ConnectedAt.Before(clients[j].ConnectedAt)
+		}
+		return clients[i].ID < clients[j].ID
+	})
+	return clients
+}
+
+func (n *Notifier) SubscriberCounts() map[domain.Stock]int {
+	counts := make(map[domain.Stock]int)
+	n.subscriptions.Range(func(key, value interface{}) bool {
+		count := 0
+		value.(*sync.Map).Range(func(_, _ interface{}) bool {
+			count++
+			return true
+		})
+		if count > 0 {
+			counts[key.(domain.Stock)] = count
+		}
+		return true
+	})
+	return counts
+}
+
+// DropSymbol ends every ticker and candle subscription to stock and sends each
+// affected client a symbol_removed status. Price updates already queued are
+// still delivered ahead of it.
+func (n *Notifier) DropSymbol(stock domain.Stock) int {
+	affected := make(map[ports.WebSocketConn]struct{})
+	if clients, ok := n.subscriptions.Load(stock); ok {
+		clients.(*sync.Map).Range(func(key, value interface{}) bool {
+			if sub, loaded := clients.(*sync.Map).LoadAndDelete(key); loaded {
+				sub.(*subscription).stop()
+			}
+			affected[key.(ports.WebSocketConn)] = struct{}{}
+			return true
+		})
+	}
+	n.candleSubs.Range(func(key, value interface{}) bool {
+		if key.(candleTopic).stock != stock {
+			return true
+		}
+		value.(*sync.Map).Range(func(ws, _ interface{}) bool {
+			value.(*sync.Map).Delete(ws)
+			affected[ws.(ports.WebSocketConn)] = struct{}{}
+			return true
+		})
+		return true
+	})
+
+	status := domain.StatusMessage{
+		Type:    domain.StatusType,
+		Status:  domain.StatusSymbolRemoved,
+		Stock:   stock,
+		Message: "symbol removed by operator; subscriptions to it were dropped",
+	}
+	for ws := range affected {
+		if err := n.Send(ws, status); err != nil {
+			n.logger.Errorf("Error telling client %v that %v was removed: %v", ws.RemoteAddr(), stock, err)
+		}
+	}
+	return len(affected)
+}
+
+// Disconnect sends the client with id a close frame and closes its
+// connection.
+func (n *Notifier) Disconnect(id string) error {
+	var target ports.WebSocketConn
+	n.conns.Range(func(key, value interface{}) bool {
+		if value.(*client).id == id {
+			target = key.(ports.WebSocketConn)
+			return false
+		}
+		return true
+	})
+	if target == nil {
+		return fmt.Errorf("%w: %s", domain.ErrUnknownClient, id)
+	}
+
+	frame := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "disconnected by operator")
+	if err := target.WriteControl(websocket.CloseMessage, frame, time.Now().Add(closeWait)); err != nil {
+		n.logger.Errorf("Error sending close frame to %v: %v", target.RemoteAddr(), err)
+	}
+	n.disconnect(target)
+	n.logger.Infof("Operator disconnected client %v (%v)", id, target.RemoteAddr())
+	return nil
+}
@@ -0,0 +1,125 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestNotifier_Clients(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	other := mocks.NewMockWebSocketConn(deps.ctrl)
+	other.EXPECT().Subprotocol().Return("").AnyTimes()
+	other.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
+	other.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+
+	deps.notifier.AddClient(deps.mockConn)
+	deps.notifier.AddClient(other)
+	_ = deps.notifier.Subscribe(deps.mockConn, "ETH-USD", domain.SubscriptionOptions{})
+	_ = deps.notifier.Subscribe(deps.mockConn, aStock, domain.SubscriptionOptions{})
+	_ = deps.notifier.Subscribe(other, aStock, domain.SubscriptionOptions{})
+	_ = deps.notifier.SubscribeCandles(other, aStock, domain.Interval5m)
+
+	clients := deps.notifier.Clients()
+
+	assert.Len(t, clients, 2)
+	assert.Equal(t, "client-1", clients[0].ID)
+	assert.Equal(t, []domain.Stock{aStock, "ETH-USD"}, clients[0].Subscriptions)
+	assert.Empty(t, clients[0].CandleSubscriptions)
+	assert.Equal(t, "client-2", clients[1].ID)
+	assert.Equal(t, []domain.CandleSubscription{{Stock: aStock, Interval: domain.Interval5m}}, clients[1].CandleSubscriptions)
+	assert.False(t, clients[0].ConnectedAt.IsZero())
+
+	assert.Equal(t, map[domain.Stock]int{aStock: 2, "ETH-USD": 1}, deps.notifier.SubscriberCounts())
+}
+
+func TestNotifier_Disconnect(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	deps.notifier.AddClient(deps.mockConn)
+	id := deps.notifier.Clients()[0].ID
+
+	frame := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "disconnected by operator")
+	gomock.InOrder(
+		deps.mockConn.EXPECT().WriteControl(websocket.CloseMessage, frame, gomock.Any()).Return(nil),
+		deps.mockConn.EXPECT().Close().Return(nil),
+	)
+
+	assert.NoError(t, deps.notifier.Disconnect(id))
+	assert.Empty(t, deps.notifier.GetConnections())
+
+	err := deps.notifier.Disconnect(id)
+	assert.True(t, errors.Is(err, domain.ErrUnknownClient))
+}
+
+func TestNotifier_ClientsReportsQueueDepth(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	release := make(chan struct{})
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	// The first write blocks, so the next two messages stay queued.
+	deps.mockConn.EXPECT().WriteMessage(gomock.Any(), gomock.Any()).DoAndReturn(func(int, []byte) error {
+		<-release
+		return nil
+	}).Times(3)
+	deps.notifier.AddClient(deps.mockConn)
+	_ = deps.notifier.Send(deps.mockConn, "first")
+	assert.Eventually(t, func() bool { return deps.notifier.Clients()[0].QueueDepth == 0 }, time.Second, time.Millisecond)
+	_ = deps.notifier.Send(deps.mockConn, "second")
+	_ = deps.notifier.Send(deps.mockConn, "third")
+
+	assert.Equal(t, 2, deps.notifier.Clients()[0].QueueDepth)
+
+	close(release)
+	assert.Eventually(t, deps.ctrl.Satisfied, time.Second, time.Millisecond)
+}
+
+func TestNotifier_DropSymbol(t *testing.T) {
+	deps := setup(t)
+	defer deps.ctrl.Finish()
+
+	candles := mocks.NewMockWebSocketConn(deps.ctrl)
+	candles.EXPECT().Subprotocol().Return("").AnyTimes()
+	candles.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
+	candles.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	bystander := mocks.NewMockWebSocketConn(deps.ctrl)
+	bystander.EXPECT().Subprotocol().Return("").AnyTimes()
+	bystander.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
+	bystander.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	deps.mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+
+	_ = deps.notifier.Subscribe(deps.mockConn, "DOGE-USD", domain.SubscriptionOptions{})
+	_ = deps.notifier.SubscribeCandles(candles, "DOGE-USD", domain.Interval1m)
+	_ = deps.notifier.Subscribe(bystander, aStock, domain.SubscriptionOptions{})
+
+	removed, _ := json.Marshal(domain.StatusMessage{
+		Type:    domain.StatusType,
+		Status:  domain.StatusSymbolRemoved,
+		Stock:   "DOGE-USD",
+		Message: "symbol removed by operator; subscriptions to it were dropped",
+	})
+	deps.mockConn.EXPECT().WriteMessage(websocket.TextMessage, removed).Return(nil)
+	candles.EXPECT().WriteMessage(websocket.TextMessage, removed).Return(nil)
+
+	assert.Equal(t, 2, deps.notifier.DropSymbol("DOGE-USD"))
+
+	assert.Empty(t, deps.notifier.GetSubscriptions("DOGE-USD"))
+	assert.Equal(t, map[domain.Stock]int{aStock: 1}, deps.notifier.SubscriberCounts())
+	for _, client := range deps.notifier.Clients() {
+		assert.Empty(t, client.CandleSubscriptions)
+	}
+	assert.Eventually(t, deps.ctrl.Satisfied, time.Second, time.Millisecond)
+}
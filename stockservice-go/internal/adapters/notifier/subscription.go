@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+)
+
+// subscription holds one client's options for one stock.
+type subscription struct {
+	fields []string
+	// encoding identifies the client's wire format and field selection so
+	// subscribers sharing both share one encoded message per event.
+	encoding encodingKey
+
+	// maxRate, when set, limits delivery to one event per window. Events
+	// arriving inside a window are conflated and the latest is delivered
+	// when the window ends.
+	maxRate time.Duration
+	deliver func(msg outboundMessage)
+
+	mu       sync.Mutex
+	lastSent time.Time
+	pending  *outboundMessage
+	timer    *time.Timer
+	stopped  bool
+}
+
+func newSubscription(opts domain.SubscriptionOptions, format domain.WireFormat, deliver func(msg outboundMessage)) *subscription {
+	return &subscription{
+		fields:   opts.Fields,
+		encoding: encodingKey{format: format, projection: strings.Join(opts.Fields, ",")},
+		maxRate:  opts.MaxRate,
+		deliver:  deliver,
+	}
+}
+
+// publish delivers msg right away when the subscriber is unthrottled or its
+// current window has elapsed, otherwise it keeps msg as the pending update for
+// the end of the window.
+func (s *subscription) publish(msg outboundMessage) {
+	if s.maxRate <= 0 {
+		s.deliver(msg)
+		return
+	}
+
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	if s.timer == nil && now.Sub(s.lastSent) >= s.maxRate {
+		s.lastSent = now
+		s.mu.Unlock()
+		s.deliver(msg)
+		return
+	}
+
+	s.pending = &msg
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.lastSent.Add(s.maxRate).Sub(now), s.flush)
+	}
+	s.mu.Unlock()
+}
+
+func (s *subscription) flush() {
+	s.mu.Lock()
+	s.timer = nil
+	pending := s.pending
+	s.pending = nil
+	if s.stopped || pending == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSent = time.Now()
+	s.mu.Unlock()
+
+	s.deliver(*pending)
+}
+
+func (s *subscription) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	s.pending = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type deliveryRecorder struct {
+	mu        sync.Mutex
+	delivered []string
+}
+
+func (r *deliveryRecorder) deliver(msg outboundMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delivered = append(r.delivered, string(msg.data))
+}
+
+func (r *deliveryRecorder) messages() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.delivered...)
+}
+
+func TestSubscription_Publish_Unthrottled(t *testing.T) {
+	recorder := &deliveryRecorder{}
+	sub := newSubscription(domain.SubscriptionOptions{}, domain.WireJSON, recorder.deliver)
+
+	sub.publish(priceMessage(aStock, "1"))
+	sub.publish(priceMessage(aStock, "2"))
+
+	assert.Equal(t, []string{"1", "2"}, recorder.messages())
+}
+
+func TestSubscription_Publish_ConflatesWithinWindow(t *testing.T) {
+	recorder := &deliveryRecorder{}
+	sub := newSubscription(domain.SubscriptionOptions{MaxRate: 50 * time.Millisecond}, domain.WireJSON, recorder.deliver)
+
+	sub.publish(priceMessage(aStock, "1"))
+	sub.publish(priceMessage(aStock, "2"))
+	sub.publish(priceMessage(aStock, "3"))
+
+	assert.Equal(t, []string{"1"}, recorder.messages(), "first event is delivered without delay")
+	assert.Eventually(t, func() bool {
+		return len(recorder.messages()) == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"1", "3"}, recorder.messages())
+}
+
+func TestSubscription_Publish_QuietTrafficIsNotDelayed(t *testing.T) {
+	recorder := &deliveryRecorder{}
+	sub := newSubscription(domain.SubscriptionOptions{MaxRate: 10 * time.Millisecond}, domain.WireJSON, recorder.deliver)
+
+	sub.publish(priceMessage(aStock, "1"))
+	time.Sleep(20 * time.Millisecond)
+	sub.publish(priceMessage(aStock, "2"))
+
+	assert.Equal(t, []string{"1", "2"}, recorder.messages())
+}
+
+func TestSubscription_Stop_DiscardsPending(t *testing.T) {
+	recorder := &deliveryRecorder{}
+	sub := newSubscription(domain.SubscriptionOptions{MaxRate: 20 * time.Millisecond}, domain.WireJSON, recorder.deliver)
+
+	sub.publish(priceMessage(aStock, "1"))
+	sub.publish(priceMessage(aStock, "2"))
+	sub.stop()
+	sub.publish(priceMessage(aStock, "3"))
+	time.Sleep(40 * time.Millisecond)
+
+	assert.Equal(t, []string{"1"}, recorder.messages())
+}
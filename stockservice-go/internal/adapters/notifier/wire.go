@@ -0,0 +1,138 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/gorilla/websocket"
+	"github.com/ugorji/go/codec"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// msgpackHandle encodes with the same keys as JSON and times as the msgpack
+// timestamp extension.
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// encodingKey identifies one encoding of an event, so subscribers sharing a
+// format and projection share the encoded bytes.
+type encodingKey struct {
+	format     domain.WireFormat
+	projection string
+}
+
+// encodeEvent encodes event in format, restricted to fields when set, and
+// returns the WebSocket message type to send it as.
+func encodeEvent(event *domain.PriceEvent, fields []string, format domain.WireFormat) ([]byte, int, error) {
+	switch format {
+	case domain.WireProtobuf:
+		return encodeProtobuf(event, fields), websocket.BinaryMessage, nil
+	case domain.WireMsgPack:
+		var v interface{} = event
+		if fields != nil {
+			v = event.Project(fields)
+		}
+		var buf bytes.Buffer
+		if err := codec.NewEncoder(&buf, msgpackHandle).Encode(v); err != nil {
+			return nil, 0, err
+		}
+		return buf.Bytes(), websocket.BinaryMessage, nil
+	default:
+		var v interface{} = event
+		if fields != nil {
+			v = event.Project(fields)
+		}
+		data, err := json.Marshal(v)
+		return data, websocket.TextMessage, err
+	}
+}
+
+type protoField struct {
+	name   string
+	append func(b []byte, e *domain.PriceEvent) []byte
+}
+
+// priceEventProto mirrors message PriceEvent in api/pricefeed.proto. Field
+// numbers must never be reused or renumbered.
+var priceEventProto = []protoField{
+	{"Type", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoString(b, 1, e.Type) }},
+	{"Sequence", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoInt64(b, 2, e.Sequence) }},
+	{"ProductID", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoString(b, 3, string(e.ProductID)) }},
+	{"Price", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 4, e.Price) }},
+	{"Open24H", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 5, e.Open24H) }},
+	{"Volume24H", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 6, e.Volume24H) }},
+	{"Low24H", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 7, e.Low24H) }},
+	{"High24H", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 8, e.High24H) }},
+	{"Volume30D", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 9, e.Volume30D) }},
+	{"BestBid", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 10, e.BestBid) }},
+	{"BestBidSize", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 11, e.BestBidSize) }},
+	{"BestAsk", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 12, e.BestAsk) }},
+	{"BestAskSize", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 13, e.BestAskSize) }},
+	{"Side", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoString(b, 14, e.Side) }},
+	{"Time", appendProtoTime},
+	{"TradeId", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoInt64(b, 16, e.TradeId) }},
+	{"LastSize", func(b []byte, e *domain.PriceEvent) []byte { return appendProtoDouble(b, 17, e.LastSize) }},
+}
+
+const protoSnapshotField = 18
+
+// encodeProtobuf encodes event as a pricefeed.v1.PriceEvent. Zero values are
+// omitted, as proto3 does, and so are fields outside the projection.
+func encodeProtobuf(event *domain.PriceEvent, fields []string) []byte {
+	var include map[string]bool
+	if fields != nil {
+		include = make(map[string]bool, len(fields))
+		for _, name := range fields {
+			include[name] = true
+		}
+	}
+
+	var b []byte
+	for _, field := range priceEventProto {
+		if include == nil || include[field.name] {
+			b = field.append(b, event)
+		}
+	}
+	if event.Snapshot {
+		b = protowire.AppendTag(b, protoSnapshotField, protowire.VarintType)
+		b = protowire.AppendVarint(b, protowire.EncodeBool(true))
+	}
+	return b
+}
+
+func appendProtoString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendProtoInt64(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendProtoDouble(b []byte, num protowire.Number, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+// appendProtoTime encodes Time as a google.protobuf.Timestamp in field 15.
+func appendProtoTime(b []byte, e *domain.PriceEvent) []byte {
+	if e.Time.IsZero() {
+		return b
+	}
+	var ts []byte
+	ts = appendProtoInt64(ts, 1, e.Time.Unix())
+	ts = appendProtoInt64(ts, 2, int64(e.Time.Nanosecond()))
+	b = protowire.AppendTag(b, 15, protowire.BytesType)
+	return protowire.AppendBytes(b, ts)
+}
@@ -0,0 +1,142 @@
+package notifier
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var wireEvent = &domain.PriceEvent{
+	Type:      "ticker",
+	Sequence:  42,
+	ProductID: aStock,
+	Price:     50000.5,
+	BestBid:   49999,
+	Time:      time.Date(2024, 4, 27, 14, 23, 55, 500, time.UTC),
+	Snapshot:  true,
+}
+
+// decodeProto returns the raw value of each top-level field by number.
+func decodeProto(t *testing.T, b []byte) map[protowire.Number][]byte {
+	fields := make(map[protowire.Number][]byte)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		assert.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		m := protowire.ConsumeFieldValue(num, typ, b)
+		assert.GreaterOrEqual(t, m, 0)
+		fields[num] = b[:m]
+		b = b[m:]
+	}
+	return fields
+}
+
+func TestEncodeEvent_Protobuf(t *testing.T) {
+	data, messageType, err := encodeEvent(wireEvent, nil, domain.WireProtobuf)
+	assert.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, messageType)
+
+	fields := decodeProto(t, data)
+
+	product, _ := protowire.ConsumeString(fields[3])
+	assert.Equal(t, string(aStock), product)
+	sequence, _ := protowire.ConsumeVarint(fields[2])
+	assert.Equal(t, uint64(42), sequence)
+	price, _ := protowire.ConsumeFixed64(fields[4])
+	assert.Equal(t, 50000.5, math.Float64frombits(price))
+	snapshot, _ := protowire.ConsumeVarint(fields[protoSnapshotField])
+	assert.True(t, protowire.DecodeBool(snapshot))
+	assert.NotContains(t, fields, protowire.Number(5), "zero values are omitted")
+
+	raw, _ := protowire.ConsumeBytes(fields[15])
+	var ts timestamppb.Timestamp
+	assert.NoError(t, proto.Unmarshal(raw, &ts))
+	assert.Equal(t, wireEvent.Time, ts.AsTime())
+}
+
+func TestEncodeEvent_ProtobufProjection(t *testing.T) {
+	data, _, err := encodeEvent(wireEvent, []string{"ProductID", "Price"}, domain.WireProtobuf)
+	assert.NoError(t, err)
+
+	fields := decodeProto(t, data)
+	assert.Len(t, fields, 3) // product_id, price and snapshot
+	assert.Contains(t, fields, protowire.Number(3))
+	assert.Contains(t, fields, protowire.Number(4))
+}
+
+func TestEncodeEvent_MsgPack(t *testing.T) {
+	data, messageType, err := encodeEvent(wireEvent, nil, domain.WireMsgPack)
+	assert.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, messageType)
+
+	var decoded map[string]interface{}
+	assert.NoError(t, codec.NewDecoderBytes(data, msgpackHandle).Decode(&decoded))
+	assert.Equal(t, 50000.5, decoded["Price"])
+	assert.Equal(t, "BTC-USD", decoded["ProductID"])
+	assert.Equal(t, wireEvent.Time, decoded["Time"])
+	assert.Equal(t, true, decoded["Snapshot"])
+
+	jsonData, _, err := encodeEvent(wireEvent, nil, domain.WireJSON)
+	assert.NoError(t, err)
+	assert.Less(t, len(data), len(jsonData))
+}
+
+func TestEncodeEvent_MsgPackProjection(t *testing.T) {
+	data, _, err := encodeEvent(wireEvent, []string{"ProductID", "Price"}, domain.WireMsgPack)
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	assert.NoError(t, codec.NewDecoderBytes(data, msgpackHandle).Decode(&decoded))
+	assert.Len(t, decoded, 3)
+	assert.Equal(t, 50000.5, decoded["Price"])
+}
+
+func TestNotifier_Broadcast_EncodesOncePerFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	notifier := NewNotifier(&mocks.StubLogger{})
+	conns := map[*mocks.MockWebSocketConn]string{
+		mocks.NewMockWebSocketConn(ctrl): "",
+		mocks.NewMockWebSocketConn(ctrl): "protobuf",
+		mocks.NewMockWebSocketConn(ctrl): "protobuf",
+		mocks.NewMockWebSocketConn(ctrl): "msgpack",
+	}
+
+	event := &domain.PriceEvent{ProductID: aStock, Price: 50000.00}
+	jsonMsg, err := json.Marshal(event)
+	assert.NoError(t, err)
+	protoMsg, _, err := encodeEvent(event, nil, domain.WireProtobuf)
+	assert.NoError(t, err)
+	msgpackMsg, _, err := encodeEvent(event, nil, domain.WireMsgPack)
+	assert.NoError(t, err)
+
+	for conn, subprotocol := range conns {
+		conn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+		conn.EXPECT().Subprotocol().Return(subprotocol).AnyTimes()
+		conn.EXPECT().SetWriteDeadline(gomock.Any()).Return(nil).AnyTimes()
+		switch subprotocol {
+		case "protobuf":
+			conn.EXPECT().WriteMessage(websocket.BinaryMessage, protoMsg).Return(nil)
+		case "msgpack":
+			conn.EXPECT().WriteMessage(websocket.BinaryMessage, msgpackMsg).Return(nil)
+		default:
+			conn.EXPECT().WriteMessage(websocket.TextMessage, jsonMsg).Return(nil)
+		}
+		_ = notifier.Subscribe(conn, aStock, domain.SubscriptionOptions{})
+	}
+
+	assert.NoError(t, notifier.Broadcast(event))
+	assert.Eventually(t, ctrl.Satisfied, time.Second, time.Millisecond)
+}
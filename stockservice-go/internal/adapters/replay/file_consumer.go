@@ -0,0 +1,180 @@
+package replay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/adapters/dtos"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+)
+
+// maxLineSize bounds a single JSON line, well above any price event.
+const maxLineSize = 1 << 20
+
+// FileConsumer replays PriceEventDTO JSON lines from a file, or from every
+// file in a directory in name order, in place of a Kafka topic.
+type FileConsumer struct {
+	path     string
+	speed    float64
+	handler  func(event *domain.PriceEvent) error
+	onStatus func(status domain.FeedStatus)
+	logger   ports.Logger
+}
+
+type Option func(*FileConsumer)
+
+// WithSpeed scales the gaps between event timestamps: 1 keeps the original
+// timing, 10 replays ten times faster. Zero or less replays as fast as
+// possible.
+func WithSpeed(multiplier float64) Option {
+	return func(c *FileConsumer) {
+		c.speed = multiplier
+	}
+}
+
+func NewFileConsumer(path string, logger ports.Logger, opts ...Option) *FileConsumer {
+	c := &FileConsumer{
+		path:   path,
+		speed:  1,
+		logger: logger,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *FileConsumer) SetListener(handlePriceEvent func(event *domain.PriceEvent) error) {
+	c.handler = handlePriceEvent
+}
+
+func (c *FileConsumer) SetStatusListener(handleStatus func(status domain.FeedStatus)) {
+	c.onStatus = handleStatus
+}
+
+// Start replays every event, then keeps the last prices served until ctx is
+// done. It fails only if the files cannot be listed or opened.
+func (c *FileConsumer) Start(ctx context.Context) error {
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	if c.onStatus != nil {
+		c.onStatus(domain.StatusConnected)
+	}
+
+	var last time.Time
+	for _, file := range files {
+		if last, err = c.replayFile(ctx, file, last); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			c.logger.Info("FileConsumer context canceled")
+			return nil
+		}
+	}
+
+	c.logger.Infof("Replay of %v finished", c.path)
+	<-ctx.Done()
+	return nil
+}
+
+// files lists the files to replay: path itself, or the regular, non-hidden
+// files of the directory at path sorted by name.
+func (c *FileConsumer) files() ([]string, error) {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("replay source: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{c.path}, nil
+	}
+
+	entries, err := os.ReadDir(c.path)
+	if err != nil {
+		return nil, fmt.Errorf("replay source: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+			files = append(files, filepath.Join(c.path, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// replayFile replays the events of file, pacing them from last, the time of
+// the previous event. It returns the time of the last event replayed.
+func (c *FileConsumer) replayFile(ctx context.Context, file string, last time.Time) (time.Time, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return last, fmt.Errorf("replay source: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		event, err := decode(scanner.Bytes())
+		if errors.Is(err, dtos.ErrUnsupportedStock) {
+			c.logger.Debugf("Skipping %v:%d: %v", file, line, err)
+			continue
+		}
+		if err != nil {
+			c.logger.Errorf("Skipping %v:%d: %v", file, line, err)
+			continue
+		}
+
+		if !c.wait(ctx, last, event.Time) {
+			return last, nil
+		}
+		if !event.Time.IsZero() {
+			last = event.Time
+		}
+
+		if err := c.handler(event); err != nil {
+			c.logger.Errorf("Error handling %v:%d: %v", file, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		c.logger.Errorf("Error reading %v: %v", file, err)
+	}
+	return last, nil
+}
+
+// wait sleeps for the scaled gap between the previous event and the next one.
+// It returns false if ctx is done first.
+func (c *FileConsumer) wait(ctx context.Context, last, next time.Time) bool {
+	if c.speed <= 0 || last.IsZero() || !next.After(last) {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(time.Duration(float64(next.Sub(last)) / c.speed))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func decode(data []byte) (*domain.PriceEvent, error) {
+	var eventDTO dtos.PriceEventDTO
+	if err := json.Unmarshal(data, &eventDTO); err != nil {
+		return nil, err
+	}
+	return dtos.ToPriceEvent(&eventDTO)
+}
@@ -0,0 +1,160 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventLine returns a JSON line for an event with sequence seq at t.
+func eventLine(t *testing.T, seq int64, at time.Time) string {
+	dto := testutils.CreateValidPriceEventDTO()
+	dto.Sequence = seq
+	dto.Time = at.Format(time.RFC3339)
+	line, err := json.Marshal(dto)
+	require.NoError(t, err)
+	return string(line)
+}
+
+func writeFile(t *testing.T, path string, lines ...string) {
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600))
+}
+
+type recorder struct {
+	mu        sync.Mutex
+	sequences []int64
+	received  chan struct{}
+}
+
+func newRecorder() *recorder {
+	return &recorder{received: make(chan struct{}, 100)}
+}
+
+func (r *recorder) handle(event *domain.PriceEvent) error {
+	r.mu.Lock()
+	r.sequences = append(r.sequences, event.Sequence)
+	r.mu.Unlock()
+	r.received <- struct{}{}
+	return nil
+}
+
+// replay runs consumer until it has delivered n events, and returns how long
+// that took.
+func replay(t *testing.T, consumer *FileConsumer, rec *recorder, n int) time.Duration {
+	consumer.SetListener(rec.handle)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	started := time.Now()
+	go func() { done <- consumer.Start(ctx) }()
+
+	for range n {
+		select {
+		case <-rec.received:
+		case <-time.After(time.Second):
+			t.Fatal("replay did not deliver every event")
+		}
+	}
+	elapsed := time.Since(started)
+
+	select {
+	case err := <-done:
+		t.Fatalf("Start returned before shutdown: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	cancel()
+	assert.NoError(t, <-done)
+	return elapsed
+}
+
+func TestFileConsumer_ReplaysFileAsFastAsPossible(t *testing.T) {
+	base := time.Date(2024, 4, 27, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "prices.jsonl")
+	writeFile(t, path,
+		eventLine(t, 1, base),
+		"",
+		"not json",
+		eventLine(t, 2, base.Add(time.Hour)),
+		eventLine(t, 3, base.Add(2*time.Hour)),
+	)
+	consumer := NewFileConsumer(path, &mocks.StubLogger{}, WithSpeed(0))
+	var statuses []domain.FeedStatus
+	consumer.SetStatusListener(func(status domain.FeedStatus) { statuses = append(statuses, status) })
+	rec := newRecorder()
+
+	replay(t, consumer, rec, 3)
+
+	assert.Equal(t, []int64{1, 2, 3}, rec.sequences)
+	assert.Equal(t, []domain.FeedStatus{domain.StatusConnected}, statuses)
+}
+
+func TestFileConsumer_ReplaysDirectoryInNameOrder(t *testing.T) {
+	base := time.Date(2024, 4, 27, 0, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "2024-04-28.jsonl"), eventLine(t, 3, base.Add(24*time.Hour)))
+	writeFile(t, filepath.Join(dir, "2024-04-27.jsonl"), eventLine(t, 1, base), eventLine(t, 2, base.Add(time.Hour)))
+	writeFile(t, filepath.Join(dir, ".hidden"), eventLine(t, 99, base))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0o700))
+	rec := newRecorder()
+
+	replay(t, NewFileConsumer(dir, &mocks.StubLogger{}, WithSpeed(0)), rec, 3)
+
+	assert.Equal(t, []int64{1, 2, 3}, rec.sequences)
+}
+
+func TestFileConsumer_ScalesOriginalTiming(t *testing.T) {
+	base := time.Date(2024, 4, 27, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "prices.jsonl")
+	writeFile(t, path,
+		eventLine(t, 1, base),
+		eventLine(t, 2, base.Add(time.Hour)),
+		eventLine(t, 3, base.Add(time.Hour)),
+	)
+	// One hour of events replayed in 36ms.
+	rec := newRecorder()
+
+	elapsed := replay(t, NewFileConsumer(path, &mocks.StubLogger{}, WithSpeed(100_000)), rec, 3)
+
+	assert.GreaterOrEqual(t, elapsed, 36*time.Millisecond)
+	assert.Equal(t, []int64{1, 2, 3}, rec.sequences)
+}
+
+func TestFileConsumer_StopsWaitingOnShutdown(t *testing.T) {
+	base := time.Date(2024, 4, 27, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "prices.jsonl")
+	writeFile(t, path, eventLine(t, 1, base), eventLine(t, 2, base.Add(time.Hour)))
+	consumer := NewFileConsumer(path, &mocks.StubLogger{})
+	rec := newRecorder()
+	consumer.SetListener(rec.handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- consumer.Start(ctx) }()
+	<-rec.received
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("replay kept waiting after shutdown")
+	}
+	assert.Equal(t, []int64{1}, rec.sequences)
+}
+
+func TestFileConsumer_MissingSource(t *testing.T) {
+	consumer := NewFileConsumer(filepath.Join(t.TempDir(), "missing.jsonl"), &mocks.StubLogger{})
+	consumer.SetListener(func(event *domain.PriceEvent) error { return nil })
+
+	assert.Error(t, consumer.Start(context.Background()))
+}
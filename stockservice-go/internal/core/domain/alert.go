@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type AlertCondition string
+
+const (
+	// CrossesAbove fires when the price moves from below Threshold to at or
+	// above it.
+	CrossesAbove AlertCondition = "crosses_above"
+	// CrossesBelow fires when the price moves from above Threshold to at or
+	// below it.
+	CrossesBelow AlertCondition = "crosses_below"
+	// MovesPercent fires when the price moves by at least Threshold percent,
+	// up or down, within Window.
+	MovesPercent AlertCondition = "moves_percent"
+)
+
+const (
+	AlertTriggeredType = "alert_triggered"
+	AlertListType      = "alert_list"
+)
+
+var (
+	ErrUnknownAlert  = errors.New("unknown alert")
+	ErrTooManyAlerts = errors.New("too many alerts")
+)
+
+// AlertSpec is the rule a client registers with alert_create.
+type AlertSpec struct {
+	Condition AlertCondition `json:"condition"`
+	Threshold float64        `json:"threshold"`
+	// Window is a Go duration such as "1h", used by MovesPercent only.
+	Window string `json:"window,omitempty"`
+	// Rearm keeps the alert after it fires. Crossing alerts fire again on the
+	// next crossing; MovesPercent alerts start a fresh window.
+	Rearm bool `json:"rearm,omitempty"`
+}
+
+// Validate checks the spec and returns the parsed window, which is zero for
+// crossing conditions.
+func (s AlertSpec) Validate() (time.Duration, error) {
+	if s.Threshold <= 0 {
+		return 0, fmt.Errorf("threshold must be positive")
+	}
+
+	switch s.Condition {
+	case CrossesAbove, CrossesBelow:
+		return 0, nil
+	case MovesPercent:
+		window, err := time.ParseDuration(s.Window)
+		if err != nil || window <= 0 {
+			return 0, fmt.Errorf("window must be a positive duration such as \"1h\"")
+		}
+		return window, nil
+	default:
+		return 0, fmt.Errorf("unknown condition: %s", s.Condition)
+	}
+}
+
+// Alert is a rule registered by one connection.
+type Alert struct {
+	ID    string `json:"id"`
+	Stock Stock  `json:"stock"`
+	AlertSpec
+}
+
+// AlertMessage is pushed to the owning connection when an alert fires.
+type AlertMessage struct {
+	Type  string    `json:"type"`
+	Alert Alert     `json:"alert"`
+	Price float64   `json:"price"`
+	Time  time.Time `json:"time"`
+	// ChangePercent is the signed move that fired a MovesPercent alert.
+	ChangePercent float64 `json:"change_percent,omitempty"`
+}
+
+// AlertListMessage answers alert_list with the connection's alerts.
+type AlertListMessage struct {
+	Type   string  `json:"type"`
+	ID     string  `json:"id,omitempty"`
+	Alerts []Alert `json:"alerts"`
+}
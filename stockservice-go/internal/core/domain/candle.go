@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+type CandleInterval string
+
+const (
+	Interval1m  CandleInterval = "1m"
+	Interval5m  CandleInterval = "5m"
+	Interval15m CandleInterval = "15m"
+	Interval1h  CandleInterval = "1h"
+	Interval1d  CandleInterval = "1d"
+)
+
+// CandleIntervals lists every interval the service aggregates.
+var CandleIntervals = []CandleInterval{Interval1m, Interval5m, Interval15m, Interval1h, Interval1d}
+
+var candleDurations = map[CandleInterval]time.Duration{
+	Interval1m:  time.Minute,
+	Interval5m:  5 * time.Minute,
+	Interval15m: 15 * time.Minute,
+	Interval1h:  time.Hour,
+	Interval1d:  24 * time.Hour,
+}
+
+func ParseCandleInterval(value string) (CandleInterval, bool) {
+	interval := CandleInterval(value)
+	_, ok := candleDurations[interval]
+	return interval, ok
+}
+
+func (i CandleInterval) Duration() time.Duration {
+	return candleDurations[i]
+}
+
+// Candle is an OHLCV bar built from ticker Price and LastSize. Start is
+// aligned to the interval in UTC.
+type Candle struct {
+	Stock    Stock          `json:"stock"`
+	Interval CandleInterval `json:"interval"`
+	Start    time.Time      `json:"start"`
+	Open     float64        `json:"open"`
+	High     float64        `json:"high"`
+	Low      float64        `json:"low"`
+	Close    float64        `json:"close"`
+	Volume   float64        `json:"volume"`
+	Closed   bool           `json:"closed"`
+}
+
+func (c *Candle) End() time.Time {
+	return c.Start.Add(c.Interval.Duration())
+}
+
+const (
+	CandleUpdateType = "candle_update"
+	CandleClosedType = "candle_closed"
+)
+
+type CandleMessage struct {
+	Type   string  `json:"type"`
+	Candle *Candle `json:"candle"`
+}
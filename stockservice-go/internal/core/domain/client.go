@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUnknownClient = errors.New("unknown client")
+
+type CandleSubscription struct {
+	Stock    Stock          `json:"stock"`
+	Interval CandleInterval `json:"interval"`
+}
+
+// ClientInfo describes a connected streaming client for operators.
+type ClientInfo struct {
+	ID                  string               `json:"id"`
+	RemoteAddr          string               `json:"remote_addr"`
+	ConnectedAt         time.Time            `json:"connected_at"`
+	Subscriptions       []Stock              `json:"subscriptions"`
+	CandleSubscriptions []CandleSubscription `json:"candle_subscriptions,omitempty"`
+	// QueueDepth is the number of messages waiting to be written.
+	QueueDepth int `json:"queue_depth"`
+}
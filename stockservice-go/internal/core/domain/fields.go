@@ -0,0 +1,95 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+type priceEventField struct {
+	name  string
+	value func(e *PriceEvent) interface{}
+}
+
+// priceEventFields lists the projectable fields in wire order. Names match the
+// JSON keys of a full PriceEvent.
+var priceEventFields = []priceEventField{
+	{"Type", func(e *PriceEvent) interface{} { return e.Type }},
+	{"Sequence", func(e *PriceEvent) interface{} { return e.Sequence }},
+	{"ProductID", func(e *PriceEvent) interface{} { return e.ProductID }},
+	{"Price", func(e *PriceEvent) interface{} { return e.Price }},
+	{"Open24H", func(e *PriceEvent) interface{} { return e.Open24H }},
+	{"Volume24H", func(e *PriceEvent) interface{} { return e.Volume24H }},
+	{"Low24H", func(e *PriceEvent) interface{} { return e.Low24H }},
+	{"High24H", func(e *PriceEvent) interface{} { return e.High24H }},
+	{"Volume30D", func(e *PriceEvent) interface{} { return e.Volume30D }},
+	{"BestBid", func(e *PriceEvent) interface{} { return e.BestBid }},
+	{"BestBidSize", func(e *PriceEvent) interface{} { return e.BestBidSize }},
+	{"BestAsk", func(e *PriceEvent) interface{} { return e.BestAsk }},
+	{"BestAskSize", func(e *PriceEvent) interface{} { return e.BestAskSize }},
+	{"Side", func(e *PriceEvent) interface{} { return e.Side }},
+	{"Time", func(e *PriceEvent) interface{} { return e.Time }},
+	{"TradeId", func(e *PriceEvent) interface{} { return e.TradeId }},
+	{"LastSize", func(e *PriceEvent) interface{} { return e.LastSize }},
+}
+
+func fieldKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// ParseFields validates client-requested field names and returns them in
+// canonical spelling and wire order. Names are matched case-insensitively and
+// may use snake_case, so "best_bid" selects BestBid. ProductID is always
+// included so every message identifies its stock. An empty list selects every
+// field and yields nil.
+func ParseFields(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	requested := make(map[string]bool, len(names)+1)
+	requested[fieldKey("ProductID")] = true
+	for _, name := range names {
+		key := fieldKey(name)
+		if !isPriceEventField(key) {
+			return nil, fmt.Errorf("unknown field: %s", name)
+		}
+		requested[key] = true
+	}
+
+	fields := make([]string, 0, len(requested))
+	for _, field := range priceEventFields {
+		if requested[fieldKey(field.name)] {
+			fields = append(fields, field.name)
+		}
+	}
+	return fields, nil
+}
+
+func isPriceEventField(key string) bool {
+	for _, field := range priceEventFields {
+		if fieldKey(field.name) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Project returns the selected fields of e keyed by their canonical names.
+// fields must come from ParseFields. The Snapshot marker is kept when set.
+func (e *PriceEvent) Project(fields []string) map[string]interface{} {
+	selected := make(map[string]bool, len(fields))
+	for _, name := range fields {
+		selected[name] = true
+	}
+
+	projection := make(map[string]interface{}, len(fields)+1)
+	for _, field := range priceEventFields {
+		if selected[field.name] {
+			projection[field.name] = field.value(e)
+		}
+	}
+	if e.Snapshot {
+		projection["Snapshot"] = true
+	}
+	return projection
+}
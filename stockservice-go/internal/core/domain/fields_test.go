@@ -0,0 +1,50 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := domain.ParseFields([]string{"best_ask", "Price", "bestbid", "price"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ProductID", "Price", "BestBid", "BestAsk"}, fields)
+}
+
+func TestParseFields_Empty(t *testing.T) {
+	fields, err := domain.ParseFields(nil)
+
+	assert.NoError(t, err)
+	assert.Nil(t, fields)
+}
+
+func TestParseFields_UnknownField(t *testing.T) {
+	fields, err := domain.ParseFields([]string{"Price", "Colour"})
+
+	assert.Nil(t, fields)
+	assert.EqualError(t, err, "unknown field: Colour")
+}
+
+func TestPriceEvent_Project(t *testing.T) {
+	event := &domain.PriceEvent{
+		ProductID: domain.StockBitcoin,
+		Price:     100.5,
+		BestBid:   100.0,
+		BestAsk:   101.0,
+		Volume24H: 42,
+		Snapshot:  true,
+	}
+
+	projection := event.Project([]string{"ProductID", "Price", "BestBid", "BestAsk"})
+
+	assert.Equal(t, map[string]interface{}{
+		"ProductID": domain.StockBitcoin,
+		"Price":     100.5,
+		"BestBid":   100.0,
+		"BestAsk":   101.0,
+		"Snapshot":  true,
+	}, projection)
+}
@@ -22,23 +22,116 @@ type PriceEvent struct {
 	Time        time.Time
 	TradeId     int64
 	LastSize    float64
+	// Snapshot marks a cached event replayed on subscribe rather than a live tick.
+	Snapshot bool `json:",omitempty"`
 }
 
 type SubscriptionMessage struct {
+	// ID is an optional client-supplied correlation ID echoed in the reply.
+	ID     string `json:"id,omitempty"`
 	Action Action `json:"action"`
 	Stock  Stock  `json:"stock"`
+	// Fields optionally restricts price updates to the listed PriceEvent fields.
+	Fields []string `json:"fields,omitempty"`
+	// MaxRateMs optionally caps price updates to one per window of this many
+	// milliseconds, conflated to the latest event.
+	MaxRateMs int64 `json:"max_rate_ms,omitempty"`
+	// Channel selects the ticker feed (default) or candles.
+	Channel Channel `json:"channel,omitempty"`
+	// Interval is the candle interval for the candles channel.
+	Interval CandleInterval `json:"interval,omitempty"`
+	// Alert is the rule to register with alert_create.
+	Alert *AlertSpec `json:"alert,omitempty"`
+	// AlertID names the alert to remove with alert_delete.
+	AlertID string `json:"alert_id,omitempty"`
+	// ResumeFrom is the last Sequence the client received before
+	// reconnecting. Events it missed are replayed before live updates.
+	ResumeFrom int64 `json:"resume_from,omitempty"`
+}
+
+type Channel string
+
+const (
+	ChannelTicker  Channel = "ticker"
+	ChannelCandles Channel = "candles"
+)
+
+// SubscriptionOptions tunes what a client receives for one subscription.
+type SubscriptionOptions struct {
+	// Fields restricts events to the listed fields, as returned by
+	// ParseFields. Nil sends every field.
+	Fields []string
+	// MaxRate is the minimum interval between two updates. Zero disables
+	// throttling.
+	MaxRate time.Duration
+	// ResumeFrom is the last Sequence the client has already seen. When set,
+	// buffered events after it are replayed instead of a snapshot, and events
+	// at or below it are not sent again.
+	ResumeFrom int64
+	// Ack, when set, is sent as soon as the subscription is registered,
+	// ahead of any snapshot or replayed event.
+	Ack *AckMessage
+}
+
+const ResumeGapType = "resume_gap"
+
+// ResumeGapMessage tells a resuming client that some of the events it missed
+// are no longer buffered. A snapshot of the latest price follows.
+type ResumeGapMessage struct {
+	Type       string `json:"type"`
+	Stock      Stock  `json:"stock"`
+	ResumeFrom int64  `json:"resume_from"`
+	Message    string `json:"message"`
+}
+
+type AckMessage struct {
+	Type     string         `json:"type"`
+	ID       string         `json:"id,omitempty"`
+	Action   Action         `json:"action"`
+	Stock    Stock          `json:"stock,omitempty"`
+	Channel  Channel        `json:"channel,omitempty"`
+	Interval CandleInterval `json:"interval,omitempty"`
+	AlertID  string         `json:"alert_id,omitempty"`
 }
 
 type ErrorMessage struct {
-	Type    string
-	Message string
+	Type    string    `json:"type"`
+	ID      string    `json:"id,omitempty"`
+	Code    ErrorCode `json:"code,omitempty"`
+	Message string    `json:"message"`
 }
 
+type ErrorCode string
+
+const (
+	ErrCodeInvalidMessage    ErrorCode = "invalid_message"
+	ErrCodeUnsupportedStock  ErrorCode = "unsupported_stock"
+	ErrCodeInvalidFields     ErrorCode = "invalid_fields"
+	ErrCodeInvalidMaxRate    ErrorCode = "invalid_max_rate"
+	ErrCodeInvalidResume     ErrorCode = "invalid_resume"
+	ErrCodeUnknownChannel    ErrorCode = "unknown_channel"
+	ErrCodeInvalidInterval   ErrorCode = "invalid_interval"
+	ErrCodeUnknownAction     ErrorCode = "unknown_action"
+	ErrCodeSubscribeFailed   ErrorCode = "subscribe_failed"
+	ErrCodeUnsubscribeFailed ErrorCode = "unsubscribe_failed"
+	ErrCodeNoPrice           ErrorCode = "no_price"
+	ErrCodeInvalidAlert      ErrorCode = "invalid_alert"
+	ErrCodeUnknownAlert      ErrorCode = "unknown_alert"
+	ErrCodeTooManyAlerts     ErrorCode = "too_many_alerts"
+	ErrCodeUnauthorized      ErrorCode = "unauthorized"
+	ErrCodeNotEntitled       ErrorCode = "not_entitled"
+	ErrCodeUnknownClient     ErrorCode = "unknown_client"
+	ErrCodeProtectedStock    ErrorCode = "protected_stock"
+)
+
 type Action string
 
 const (
 	Subscribe   Action = "subscribe"
 	Unsubscribe Action = "unsubscribe"
+	AlertCreate Action = "alert_create"
+	AlertDelete Action = "alert_delete"
+	AlertList   Action = "alert_list"
 )
 
 type Stock string
@@ -48,10 +141,5 @@ const (
 )
 
 var IsSupportedStock = func(stock string) bool {
-	switch Stock(stock) {
-	case StockBitcoin:
-		return true
-	default:
-		return false
-	}
+	return Symbols.IsSupported(Stock(stock))
 }
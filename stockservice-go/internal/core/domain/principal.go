@@ -0,0 +1,36 @@
+package domain
+
+import "errors"
+
+// AllStocks entitles a principal to every supported symbol.
+const AllStocks Stock = "*"
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Credentials are what a client presented when connecting. At most one of
+// them is expected to be set.
+type Credentials struct {
+	APIKey string
+	Token  string
+}
+
+// Principal is an authenticated client and the symbols it may stream.
+type Principal struct {
+	Subject string
+	Stocks  []Stock
+}
+
+// Anonymous is used for every connection when authentication is disabled.
+var Anonymous = &Principal{Subject: "anonymous", Stocks: []Stock{AllStocks}}
+
+func (p *Principal) Entitled(stock Stock) bool {
+	for _, entitled := range p.Stocks {
+		if entitled == AllStocks || entitled == stock {
+			return true
+		}
+	}
+	return false
+}
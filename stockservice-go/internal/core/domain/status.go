@@ -0,0 +1,41 @@
+package domain
+
+const StatusType = "status"
+
+// FeedStatus describes the health of the upstream price feed.
+type FeedStatus string
+
+const (
+	// StatusSequenceGap means upstream messages were skipped, so some
+	// updates for the stock never reached the service.
+	StatusSequenceGap FeedStatus = "sequence_gap"
+	// StatusSequenceRegression means a sequence number went backwards or
+	// repeated, typically after an upstream reset or a redelivery.
+	StatusSequenceRegression FeedStatus = "sequence_regression"
+
+	// StatusConnected means the service is reading from upstream normally.
+	StatusConnected FeedStatus = "connected"
+	// StatusDegraded means reads are failing and being retried; prices may
+	// be delayed.
+	StatusDegraded FeedStatus = "degraded"
+	// StatusDisconnected means upstream has been unreachable for several
+	// attempts in a row, or has not been reached yet. No prices arrive.
+	StatusDisconnected FeedStatus = "disconnected"
+
+	// StatusSymbolRemoved means an operator removed the stock, so the
+	// client's subscriptions to it were dropped.
+	StatusSymbolRemoved FeedStatus = "symbol_removed"
+)
+
+// StatusMessage warns clients that the data they receive may be incomplete.
+type StatusMessage struct {
+	Type   string     `json:"type"`
+	Status FeedStatus `json:"status"`
+	Stock  Stock      `json:"stock,omitempty"`
+	// LastSequence is the last sequence seen before Sequence arrived.
+	LastSequence int64 `json:"last_sequence,omitempty"`
+	Sequence     int64 `json:"sequence,omitempty"`
+	// Missed is the number of sequence numbers skipped by a gap.
+	Missed  int64  `json:"missed,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"sort"
+	"sync"
+)
+
+// SymbolRegistry holds the set of products the service accepts. It is safe for
+// concurrent use so symbols can be registered while clients are connected.
+type SymbolRegistry struct {
+	mu      sync.RWMutex
+	symbols map[Stock]struct{}
+}
+
+func NewSymbolRegistry(stocks ...Stock) *SymbolRegistry {
+	r := &SymbolRegistry{
+		symbols: make(map[Stock]struct{}),
+	}
+	r.Register(stocks...)
+	return r
+}
+
+func (r *SymbolRegistry) Register(stocks ...Stock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, stock := range stocks {
+		if stock == "" {
+			continue
+		}
+		r.symbols[stock] = struct{}{}
+	}
+}
+
+func (r *SymbolRegistry) Unregister(stock Stock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.symbols, stock)
+}
+
+func (r *SymbolRegistry) IsSupported(stock Stock) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.symbols[stock]
+	return ok
+}
+
+func (r *SymbolRegistry) Symbols() []Stock {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stocks := make([]Stock, 0, len(r.symbols))
+	for stock := range r.symbols {
+		stocks = append(stocks, stock)
+	}
+	sort.Slice(stocks, func(i, j int) bool { return stocks[i] < stocks[j] })
+	return stocks
+}
+
+// Symbols is the registry consulted by IsSupportedStock. It starts with
+// StockBitcoin and is extended from configuration at startup.
+var Symbols = NewSymbolRegistry(StockBitcoin)
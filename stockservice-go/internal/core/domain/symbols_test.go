@@ -0,0 +1,37 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSymbolRegistry_Register(t *testing.T) {
+	registry := domain.NewSymbolRegistry(domain.StockBitcoin)
+
+	registry.Register("ETH-USD", "SOL-USD", "")
+
+	assert.True(t, registry.IsSupported(domain.StockBitcoin))
+	assert.True(t, registry.IsSupported("ETH-USD"))
+	assert.True(t, registry.IsSupported("SOL-USD"))
+	assert.Equal(t, []domain.Stock{"BTC-USD", "ETH-USD", "SOL-USD"}, registry.Symbols())
+}
+
+func TestSymbolRegistry_Unregister(t *testing.T) {
+	registry := domain.NewSymbolRegistry(domain.StockBitcoin, "ETH-USD")
+
+	registry.Unregister("ETH-USD")
+
+	assert.False(t, registry.IsSupported("ETH-USD"))
+	assert.True(t, registry.IsSupported(domain.StockBitcoin))
+}
+
+func TestIsSupportedStock_UsesRegistry(t *testing.T) {
+	assert.False(t, domain.IsSupportedStock("DOGE-USD"))
+
+	domain.Symbols.Register("DOGE-USD")
+	defer domain.Symbols.Unregister("DOGE-USD")
+
+	assert.True(t, domain.IsSupportedStock("DOGE-USD"))
+}
@@ -0,0 +1,27 @@
+package domain
+
+// WireFormat is the encoding of price updates on a WebSocket connection. It is
+// negotiated through Sec-WebSocket-Protocol, where each format is offered
+// under its own name.
+type WireFormat string
+
+const (
+	WireJSON     WireFormat = "json"
+	WireMsgPack  WireFormat = "msgpack"
+	WireProtobuf WireFormat = "protobuf"
+)
+
+// WireFormats lists the formats the server accepts, most compact first. When a
+// client offers several, the first one in this list wins.
+var WireFormats = []WireFormat{WireProtobuf, WireMsgPack, WireJSON}
+
+// ParseWireFormat maps a negotiated subprotocol to its format. Connections that
+// negotiated nothing use JSON.
+func ParseWireFormat(subprotocol string) WireFormat {
+	for _, format := range WireFormats {
+		if string(format) == subprotocol {
+			return format
+		}
+	}
+	return WireJSON
+}
@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 	"net"
+	"time"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 )
@@ -11,8 +12,17 @@ type PriceService interface {
 	StartConsuming(ctx context.Context)
 	AddClient(ws WebSocketConn)
 	RemoveClient(ws WebSocketConn)
-	Subscribe(ws WebSocketConn, stock domain.Stock) error
+	Subscribe(ws WebSocketConn, stock domain.Stock, opts domain.SubscriptionOptions) error
 	Unsubscribe(ws WebSocketConn, stock domain.Stock) error
+	Send(ws WebSocketConn, message interface{}) error
+	LatestPrice(stock domain.Stock) (*domain.PriceEvent, bool)
+	// SubscribeCandles sends ack, when set, ahead of the current candle.
+	SubscribeCandles(ws WebSocketConn, stock domain.Stock, interval domain.CandleInterval, ack *domain.AckMessage) error
+	UnsubscribeCandles(ws WebSocketConn, stock domain.Stock, interval domain.CandleInterval) error
+	Candles(stock domain.Stock, interval domain.CandleInterval, from, to time.Time) []domain.Candle
+	CreateAlert(ws WebSocketConn, stock domain.Stock, spec domain.AlertSpec) (domain.Alert, error)
+	DeleteAlert(ws WebSocketConn, id string) error
+	Alerts(ws WebSocketConn) []domain.Alert
 }
 
 type Logger interface {
@@ -26,6 +36,15 @@ type Consumer interface {
 	Start(ctx context.Context) error
 	SetListener(handlePriceEvent func(event *domain.PriceEvent) error,
 	)
+	// SetStatusListener sets the function called whenever the connection to
+	// upstream changes between domain.StatusConnected, StatusDegraded and
+	// StatusDisconnected.
+	SetStatusListener(handleStatus func(status domain.FeedStatus))
+}
+
+// FeedHealth reports the state of the upstream price feed.
+type FeedHealth interface {
+	FeedStatus() domain.FeedStatus
 }
 
 type PriceEventListener interface {
@@ -34,15 +53,72 @@ type PriceEventListener interface {
 
 type Notifier interface {
 	Broadcast(event *domain.PriceEvent) error
+	SendEvent(ws WebSocketConn, event *domain.PriceEvent) error
+	Send(ws WebSocketConn, message interface{}) error
 	AddClient(ws WebSocketConn)
 	RemoveClient(ws WebSocketConn)
-	Subscribe(ws WebSocketConn, stock domain.Stock) error
+	Subscribe(ws WebSocketConn, stock domain.Stock, opts domain.SubscriptionOptions) error
 	Unsubscribe(ws WebSocketConn, stock domain.Stock) error
+	SubscribeCandles(ws WebSocketConn, stock domain.Stock, interval domain.CandleInterval) error
+	UnsubscribeCandles(ws WebSocketConn, stock domain.Stock, interval domain.CandleInterval) error
+	BroadcastCandle(candle *domain.Candle) error
+	// BroadcastStatus sends status to the subscribers of its stock, or to
+	// every client when it names no stock.
+	BroadcastStatus(status *domain.StatusMessage) error
+}
+
+// ClientRegistry lets operators inspect and drop streaming clients.
+type ClientRegistry interface {
+	Clients() []domain.ClientInfo
+	// SubscriberCounts returns the number of ticker subscribers per stock.
+	SubscriberCounts() map[domain.Stock]int
+	// Disconnect closes the client with id, or fails with
+	// domain.ErrUnknownClient.
+	Disconnect(id string) error
+	// DropSymbol removes every ticker and candle subscription to stock,
+	// tells the affected clients, and returns how many there were.
+	DropSymbol(stock domain.Stock) int
+}
+
+type Authenticator interface {
+	// Authenticate verifies creds and returns who presented them. It fails
+	// with domain.ErrMissingCredentials or domain.ErrInvalidCredentials.
+	Authenticate(creds domain.Credentials) (*domain.Principal, error)
+}
+
+// LabeledWriter is implemented by connections that name each message, such as
+// SSE streams. The notifier passes what it already knows about a message so
+// the connection need not decode it again.
+type LabeledWriter interface {
+	// WriteLabeled writes data like WriteMessage.
+	WriteLabeled(messageType int, data []byte, label MessageLabel) error
+}
+
+// MessageLabel describes an outgoing message. Price updates carry their Stock
+// and Sequence; other messages carry their JSON "type".
+type MessageLabel struct {
+	Type     string
+	Stock    domain.Stock
+	Sequence int64
 }
 
 type WebSocketConn interface {
 	ReadMessage() (messageType int, p []byte, err error)
 	WriteMessage(messageType int, data []byte) error
+	// WriteControl sends a close, ping or pong frame. It may be called
+	// concurrently with WriteMessage.
+	WriteControl(messageType int, data []byte, deadline time.Time) error
 	Close() error
+	// SetReadDeadline makes a blocked ReadMessage fail once t has passed.
+	SetReadDeadline(t time.Time) error
+	// SetWriteDeadline makes a blocked WriteMessage fail once t has passed.
+	SetWriteDeadline(t time.Time) error
+	// SetReadLimit makes ReadMessage fail, closing the connection, on a
+	// message larger than limit bytes.
+	SetReadLimit(limit int64)
+	// SetPongHandler sets the handler run, from ReadMessage, for each pong.
+	SetPongHandler(h func(appData string) error)
 	RemoteAddr() net.Addr
+	// Subprotocol returns the negotiated Sec-WebSocket-Protocol, or "" if none.
+	Subprotocol() string
 }
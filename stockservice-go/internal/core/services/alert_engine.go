@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
+)
+
+const defaultMaxAlertsPerClient = 50
+
+type alertRule struct {
+	alert  domain.Alert
+	window time.Duration
+
+	// lastPrice is the reference for crossing conditions.
+	lastPrice float64
+	hasLast   bool
+
+	// samples holds the prices seen within window for MovesPercent.
+	samples *priceWindow
+}
+
+// AlertFiring is an alert message due to one connection.
+type AlertFiring struct {
+	WS      ports.WebSocketConn
+	Message domain.AlertMessage
+}
+
+// AlertEngine keeps the alerts registered by each connection and evaluates
+// them against incoming price events.
+type AlertEngine struct {
+	mu        sync.Mutex
+	nextID    int64
+	maxAlerts int
+	rules     map[ports.WebSocketConn][]*alertRule
+}
+
+func NewAlertEngine(maxAlertsPerClient int) *AlertEngine {
+	if maxAlertsPerClient <= 0 {
+		maxAlertsPerClient = defaultMaxAlertsPerClient
+	}
+	return &AlertEngine{
+		maxAlerts: maxAlertsPerClient,
+		rules:     make(map[ports.WebSocketConn][]*alertRule),
+	}
+}
+
+// Create registers an alert for ws. last is the latest known price of stock,
+// if any, and seeds the reference crossing conditions are measured from.
+func (e *AlertEngine) Create(ws ports.WebSocketConn, stock domain.Stock, spec domain.AlertSpec, last *domain.PriceEvent) (domain.Alert, error) {
+	window, err := spec.Validate()
+	if err != nil {
+		return domain.Alert{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.rules[ws]) >= e.maxAlerts {
+		return domain.Alert{}, fmt.Errorf("%w: at most %d per connection", domain.ErrTooManyAlerts, e.maxAlerts)
+	}
+
+	e.nextID++
+	rule := &alertRule{
+		alert: domain.Alert{
+			ID:        fmt.Sprintf("alert-%d", e.nextID),
+			Stock:     stock,
+			AlertSpec: spec,
+		},
+		window: window,
+	}
+	if spec.Condition == domain.MovesPercent {
+		rule.samples = &priceWindow{}
+	}
+	if last != nil {
+		rule.observe(last.Price, eventTime(last))
+	}
+
+	e.rules[ws] = append(e.rules[ws], rule)
+	return rule.alert, nil
+}
+
+func (e *AlertEngine) Delete(ws ports.WebSocketConn, id string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rules := e.rules[ws]
+	for i, rule := range rules {
+		if rule.alert.ID == id {
+			e.setRules(ws, append(rules[:i:i], rules[i+1:]...))
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", domain.ErrUnknownAlert, id)
+}
+
+// Alerts returns the alerts of ws in creation order.
+func (e *AlertEngine) Alerts(ws ports.WebSocketConn) []domain.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	alerts := make([]domain.Alert, 0, len(e.rules[ws]))
+	for _, rule := range e.rules[ws] {
+		alerts = append(alerts, rule.alert)
+	}
+	return alerts
+}
+
+func (e *AlertEngine) RemoveClient(ws ports.WebSocketConn) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.rules, ws)
+}
+
+// Evaluate feeds event to every alert on its stock and returns the alerts that
+// fired. One-shot alerts are removed once they fire.
+func (e *AlertEngine) Evaluate(event *domain.PriceEvent) []AlertFiring {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	at := eventTime(event)
+	var firings []AlertFiring
+	for ws, rules := range e.rules {
+		kept := rules[:0]
+		for _, rule := range rules {
+			if rule.alert.Stock != event.ProductID {
+				kept = append(kept, rule)
+				continue
+			}
+
+			change, fired := rule.evaluate(event.Price, at)
+			if fired {
+				firings = append(firings, AlertFiring{
+					WS: ws,
+					Message: domain.AlertMessage{
+						Type:          domain.AlertTriggeredType,
+						Alert:         rule.alert,
+						Price:         event.Price,
+						Time:          at,
+						ChangePercent: change,
+					},
+				})
+			}
+			if !fired || rule.alert.Rearm {
+				kept = append(kept, rule)
+			}
+		}
+		e.setRules(ws, kept)
+	}
+	return firings
+}
+
+func (e *AlertEngine) setRules(ws ports.WebSocketConn, rules []*alertRule) {
+	if len(rules) == 0 {
+		delete(e.rules, ws)
+		return
+	}
+	e.rules[ws] = rules
+}
+
+// evaluate records price and reports whether the rule fires, along with the
+// percent change for MovesPercent.
+func (r *alertRule) evaluate(price float64, at time.Time) (float64, bool) {
+	switch r.alert.Condition {
+	case domain.CrossesAbove:
+		fired := r.hasLast && r.lastPrice < r.alert.Threshold && price >= r.alert.Threshold
+		r.observe(price, at)
+		return 0, fired
+	case domain.CrossesBelow:
+		fired := r.hasLast && r.lastPrice > r.alert.Threshold && price <= r.alert.Threshold
+		r.observe(price, at)
+		return 0, fired
+	case domain.MovesPercent:
+		r.observe(price, at)
+		change := r.samples.change(price)
+		if math.Abs(change) < r.alert.Threshold {
+			return 0, false
+		}
+		// Start a fresh window so a re-arming alert does not fire on every
+		// tick of the same move.
+		r.samples.reset()
+		r.samples.add(price, at)
+		return change, true
+	default:
+		return 0, false
+	}
+}
+
+func (r *alertRule) observe(price float64, at time.Time) {
+	r.lastPrice = price
+	r.hasLast = true
+	if r.samples != nil {
+		r.samples.add(price, at)
+		r.samples.trim(at.Add(-r.window))
+	}
+}
+
+func eventTime(event *domain.PriceEvent) time.Time {
+	if event.Time.IsZero() {
+		return time.Now().UTC()
+	}
+	return event.Time
+}
+
+type priceSample struct {
+	price float64
+	at    time.Time
+}
+
+// priceWindow tracks the minimum and maximum price over a sliding time window
+// with monotonic queues, so each tick costs amortised constant time however
+// many ticks the window holds.
+type priceWindow struct {
+	mins []priceSample // increasing prices
+	maxs []priceSample // decreasing prices
+}
+
+func (w *priceWindow) add(price float64, at time.Time) {
+	for len(w.mins) > 0 && w.mins[len(w.mins)-1].price >= price {
+		w.mins = w.mins[:len(w.mins)-1]
+	}
+	w.mins = append(w.mins, priceSample{price: price, at: at})
+
+	for len(w.maxs) > 0 && w.maxs[len(w.maxs)-1].price <= price {
+		w.maxs = w.maxs[:len(w.maxs)-1]
+	}
+	w.maxs = append(w.maxs, priceSample{price: price, at: at})
+}
+
+// trim drops samples older than since.
+func (w *priceWindow) trim(since time.Time) {
+	for len(w.mins) > 1 && w.mins[0].at.Before(since) {
+		w.mins = w.mins[1:]
+	}
+	for len(w.maxs) > 1 && w.maxs[0].at.Before(since) {
+		w.maxs = w.maxs[1:]
+	}
+}
+
+// change returns the largest percent move from a price in the window to price,
+// signed by direction.
+func (w *priceWindow) change(price float64) float64 {
+	if len(w.mins) == 0 {
+		return 0
+	}
+	var up, down float64
+	if low := w.mins[0].price; low > 0 {
+		up = (price - low) / low * 100
+	}
+	if high := w.maxs[0].price; high > 0 {
+		down = (price - high) / high * 100
+	}
+	if up >= -down {
+		return up
+	}
+	return down
+}
+
+func (w *priceWindow) reset() {
+	w.mins = w.mins[:0]
+	w.maxs = w.maxs[:0]
+}
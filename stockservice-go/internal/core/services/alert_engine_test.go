@@ -0,0 +1,128 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var alertStart = time.Date(2024, 4, 27, 14, 0, 0, 0, time.UTC)
+
+func price(offset time.Duration, value float64) *domain.PriceEvent {
+	return &domain.PriceEvent{ProductID: domain.StockBitcoin, Time: alertStart.Add(offset), Price: value}
+}
+
+func TestAlertEngine_CrossesAboveOneShot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ws := mocks.NewMockWebSocketConn(ctrl)
+	engine := NewAlertEngine(0)
+
+	alert, err := engine.Create(ws, domain.StockBitcoin, domain.AlertSpec{Condition: domain.CrossesAbove, Threshold: 70000}, nil)
+	assert.NoError(t, err)
+
+	// The first price only sets the reference, even when above the threshold.
+	assert.Empty(t, engine.Evaluate(price(0, 69000)))
+	assert.Empty(t, engine.Evaluate(price(time.Second, 69999)))
+
+	firings := engine.Evaluate(price(2*time.Second, 70001))
+	assert.Len(t, firings, 1)
+	assert.Equal(t, ws, firings[0].WS)
+	assert.Equal(t, domain.AlertTriggeredType, firings[0].Message.Type)
+	assert.Equal(t, alert, firings[0].Message.Alert)
+	assert.Equal(t, 70001.0, firings[0].Message.Price)
+
+	assert.Empty(t, engine.Alerts(ws), "one-shot alerts are removed once fired")
+	assert.Empty(t, engine.Evaluate(price(3*time.Second, 69000)))
+	assert.Empty(t, engine.Evaluate(price(4*time.Second, 71000)))
+}
+
+func TestAlertEngine_CrossesBelowRearms(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ws := mocks.NewMockWebSocketConn(ctrl)
+	engine := NewAlertEngine(0)
+
+	_, err := engine.Create(ws, domain.StockBitcoin, domain.AlertSpec{Condition: domain.CrossesBelow, Threshold: 60000, Rearm: true}, price(0, 61000))
+	assert.NoError(t, err)
+
+	assert.Len(t, engine.Evaluate(price(time.Second, 59000)), 1)
+	assert.Empty(t, engine.Evaluate(price(2*time.Second, 58000)), "stays below, no new crossing")
+	assert.Empty(t, engine.Evaluate(price(3*time.Second, 61000)))
+	assert.Len(t, engine.Evaluate(price(4*time.Second, 60000)), 1)
+	assert.Len(t, engine.Alerts(ws), 1)
+}
+
+func TestAlertEngine_MovesPercentWithinWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ws := mocks.NewMockWebSocketConn(ctrl)
+	engine := NewAlertEngine(0)
+
+	spec := domain.AlertSpec{Condition: domain.MovesPercent, Threshold: 3, Window: "1h", Rearm: true}
+	_, err := engine.Create(ws, domain.StockBitcoin, spec, price(0, 100))
+	assert.NoError(t, err)
+
+	assert.Empty(t, engine.Evaluate(price(30*time.Minute, 102)))
+	// 100 has left the window by now, so the move is measured from 102.
+	assert.Empty(t, engine.Evaluate(price(61*time.Minute, 104)))
+
+	firings := engine.Evaluate(price(62*time.Minute, 98.9))
+	assert.Len(t, firings, 1)
+	assert.InDelta(t, -4.9038, firings[0].Message.ChangePercent, 0.001)
+
+	// The window restarts after firing.
+	assert.Empty(t, engine.Evaluate(price(63*time.Minute, 98.5)))
+}
+
+func TestAlertEngine_IgnoresOtherStocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ws := mocks.NewMockWebSocketConn(ctrl)
+	engine := NewAlertEngine(0)
+
+	_, err := engine.Create(ws, domain.Stock("ETH-USD"), domain.AlertSpec{Condition: domain.CrossesAbove, Threshold: 1}, nil)
+	assert.NoError(t, err)
+
+	assert.Empty(t, engine.Evaluate(price(0, 0.5)))
+	assert.Empty(t, engine.Evaluate(price(time.Second, 2)))
+}
+
+func TestAlertEngine_CreateValidatesSpec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ws := mocks.NewMockWebSocketConn(ctrl)
+	engine := NewAlertEngine(0)
+
+	specs := []domain.AlertSpec{
+		{Condition: "crosses_sideways", Threshold: 1},
+		{Condition: domain.CrossesAbove},
+		{Condition: domain.MovesPercent, Threshold: 3},
+		{Condition: domain.MovesPercent, Threshold: 3, Window: "-1h"},
+	}
+	for _, spec := range specs {
+		_, err := engine.Create(ws, domain.StockBitcoin, spec, nil)
+		assert.Error(t, err, spec)
+	}
+	assert.Empty(t, engine.Alerts(ws))
+}
+
+func TestAlertEngine_LimitDeleteAndRemoveClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ws := mocks.NewMockWebSocketConn(ctrl)
+	engine := NewAlertEngine(2)
+	spec := domain.AlertSpec{Condition: domain.CrossesAbove, Threshold: 1}
+
+	first, err := engine.Create(ws, domain.StockBitcoin, spec, nil)
+	assert.NoError(t, err)
+	second, err := engine.Create(ws, domain.StockBitcoin, spec, nil)
+	assert.NoError(t, err)
+	_, err = engine.Create(ws, domain.StockBitcoin, spec, nil)
+	assert.ErrorIs(t, err, domain.ErrTooManyAlerts)
+
+	assert.NoError(t, engine.Delete(ws, first.ID))
+	assert.ErrorIs(t, engine.Delete(ws, first.ID), domain.ErrUnknownAlert)
+	assert.Equal(t, []domain.Alert{second}, engine.Alerts(ws))
+
+	engine.RemoveClient(ws)
+	assert.Empty(t, engine.Alerts(ws))
+}
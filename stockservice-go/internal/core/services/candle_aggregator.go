@@ -0,0 +1,186 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+)
+
+const defaultCandleRetention = 500
+
+type candleKey struct {
+	stock    domain.Stock
+	interval domain.CandleInterval
+}
+
+type candleSeries struct {
+	closed  []domain.Candle
+	current *domain.Candle
+	// lastClosed is the Start of the last closed candle. Events in or before
+	// that bucket are dropped so a bucket is never closed twice.
+	lastClosed time.Time
+}
+
+// feedClock maps the local clock onto event time for one stock: the latest
+// event time seen, and when it was received.
+type feedClock struct {
+	latest   time.Time
+	received time.Time
+}
+
+// at returns the event time corresponding to the local time now.
+func (c feedClock) at(now time.Time) time.Time {
+	return c.latest.Add(now.Sub(c.received))
+}
+
+// CandleAggregator folds ticker events into OHLCV candles for every interval
+// in domain.CandleIntervals, keeping a bounded history of closed candles per
+// stock and interval.
+type CandleAggregator struct {
+	mu        sync.Mutex
+	retention int
+	series    map[candleKey]*candleSeries
+	clocks    map[domain.Stock]feedClock
+	now       func() time.Time
+}
+
+func NewCandleAggregator(retention int) *CandleAggregator {
+	if retention <= 0 {
+		retention = defaultCandleRetention
+	}
+	return &CandleAggregator{
+		retention: retention,
+		series:    make(map[candleKey]*candleSeries),
+		clocks:    make(map[domain.Stock]feedClock),
+		now:       time.Now,
+	}
+}
+
+// Add folds event into the open candles of its stock. It returns the candles
+// closed because event starts a new bucket, followed by the updated open
+// candles. Events older than the open candle, or in a bucket already closed,
+// are ignored.
+func (a *CandleAggregator) Add(event *domain.PriceEvent) (closed, updated []domain.Candle) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if clock, ok := a.clocks[event.ProductID]; !ok || event.Time.After(clock.latest) {
+		a.clocks[event.ProductID] = feedClock{latest: event.Time, received: a.now()}
+	}
+
+	for _, interval := range domain.CandleIntervals {
+		key := candleKey{stock: event.ProductID, interval: interval}
+		series, ok := a.series[key]
+		if !ok {
+			series = &candleSeries{}
+			a.series[key] = series
+		}
+
+		start := event.Time.UTC().Truncate(interval.Duration())
+		current := series.current
+		if current != nil && start.Before(current.Start) {
+			continue
+		}
+		if !series.lastClosed.IsZero() && !start.After(series.lastClosed) {
+			continue
+		}
+
+		if current != nil && start.After(current.Start) {
+			closed = append(closed, a.close(series))
+			current = nil
+		}
+
+		if current == nil {
+			current = &domain.Candle{
+				Stock:    event.ProductID,
+				Interval: interval,
+				Start:    start,
+				Open:     event.Price,
+				High:     event.Price,
+				Low:      event.Price,
+			}
+			series.current = current
+		}
+
+		current.High = max(current.High, event.Price)
+		current.Low = min(current.Low, event.Price)
+		current.Close = event.Price
+		current.Volume += event.LastSize
+		updated = append(updated, *current)
+	}
+	return closed, updated
+}
+
+// CloseExpired closes open candles whose interval has ended, so quiet stocks
+// still publish closes. Buckets follow event time, which may be skewed from
+// the local clock or, when replaying, far behind it. So each stock's time is
+// its latest event time advanced by the local time elapsed since that event
+// arrived.
+func (a *CandleAggregator) CloseExpired(now time.Time) []domain.Candle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var closed []domain.Candle
+	for key, series := range a.series {
+		if series.current != nil && !a.clocks[key.stock].at(now).Before(series.current.End()) {
+			closed = append(closed, a.close(series))
+		}
+	}
+	sort.Slice(closed, func(i, j int) bool { return closed[i].Start.Before(closed[j].Start) })
+	return closed
+}
+
+func (a *CandleAggregator) close(series *candleSeries) domain.Candle {
+	candle := *series.current
+	candle.Closed = true
+	series.current = nil
+	series.lastClosed = candle.Start
+
+	series.closed = append(series.closed, candle)
+	if len(series.closed) > a.retention {
+		series.closed = series.closed[len(series.closed)-a.retention:]
+	}
+	return candle
+}
+
+// Current returns the open candle for stock and interval, if any.
+func (a *CandleAggregator) Current(stock domain.Stock, interval domain.CandleInterval) (domain.Candle, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	series, ok := a.series[candleKey{stock: stock, interval: interval}]
+	if !ok || series.current == nil {
+		return domain.Candle{}, false
+	}
+	return *series.current, true
+}
+
+// Candles returns the retained candles, including the open one, whose start
+// lies in [from, to). Zero bounds are open-ended.
+func (a *CandleAggregator) Candles(stock domain.Stock, interval domain.CandleInterval, from, to time.Time) []domain.Candle {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	candles := []domain.Candle{}
+	series, ok := a.series[candleKey{stock: stock, interval: interval}]
+	if !ok {
+		return candles
+	}
+
+	all := series.closed
+	if series.current != nil {
+		all = append(all[:len(all):len(all)], *series.current)
+	}
+	for _, candle := range all {
+		if !from.IsZero() && candle.Start.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !candle.Start.Before(to) {
+			continue
+		}
+		candles = append(candles, candle)
+	}
+	return candles
+}
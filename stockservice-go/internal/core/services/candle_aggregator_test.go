@@ -0,0 +1,145 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func tick(at time.Time, price, size float64) *domain.PriceEvent {
+	return &domain.PriceEvent{ProductID: domain.StockBitcoin, Time: at, Price: price, LastSize: size}
+}
+
+func TestCandleAggregator_BuildsOHLCV(t *testing.T) {
+	aggregator := NewCandleAggregator(10)
+	start := time.Date(2024, 4, 27, 14, 0, 0, 0, time.UTC)
+
+	aggregator.Add(tick(start.Add(5*time.Second), 100, 1))
+	aggregator.Add(tick(start.Add(10*time.Second), 110, 2))
+	aggregator.Add(tick(start.Add(20*time.Second), 90, 0.5))
+	closed, updated := aggregator.Add(tick(start.Add(30*time.Second), 105, 1))
+
+	assert.Empty(t, closed)
+	assert.Len(t, updated, len(domain.CandleIntervals))
+
+	current, ok := aggregator.Current(domain.StockBitcoin, domain.Interval1m)
+	assert.True(t, ok)
+	assert.Equal(t, domain.Candle{
+		Stock:    domain.StockBitcoin,
+		Interval: domain.Interval1m,
+		Start:    start,
+		Open:     100,
+		High:     110,
+		Low:      90,
+		Close:    105,
+		Volume:   4.5,
+	}, current)
+}
+
+func TestCandleAggregator_ClosesOnNewBucket(t *testing.T) {
+	aggregator := NewCandleAggregator(10)
+	start := time.Date(2024, 4, 27, 14, 0, 0, 0, time.UTC)
+
+	aggregator.Add(tick(start, 100, 1))
+	closed, _ := aggregator.Add(tick(start.Add(time.Minute), 101, 1))
+
+	assert.Len(t, closed, 1)
+	assert.Equal(t, domain.Interval1m, closed[0].Interval)
+	assert.True(t, closed[0].Closed)
+	assert.Equal(t, 100.0, closed[0].Close)
+
+	candles := aggregator.Candles(domain.StockBitcoin, domain.Interval1m, time.Time{}, time.Time{})
+	assert.Len(t, candles, 2)
+	assert.True(t, candles[0].Closed)
+	assert.False(t, candles[1].Closed)
+}
+
+func TestCandleAggregator_IgnoresLateEvents(t *testing.T) {
+	aggregator := NewCandleAggregator(10)
+	start := time.Date(2024, 4, 27, 14, 1, 0, 0, time.UTC)
+
+	aggregator.Add(tick(start, 100, 1))
+	aggregator.Add(tick(start.Add(-time.Second), 50, 1))
+
+	current, ok := aggregator.Current(domain.StockBitcoin, domain.Interval1m)
+	assert.True(t, ok)
+	assert.Equal(t, 100.0, current.Low)
+}
+
+func TestCandleAggregator_CloseExpired(t *testing.T) {
+	aggregator := NewCandleAggregator(10)
+	start := time.Date(2024, 4, 27, 14, 0, 0, 0, time.UTC)
+	aggregator.now = func() time.Time { return start }
+
+	aggregator.Add(tick(start, 100, 1))
+
+	assert.Empty(t, aggregator.CloseExpired(start.Add(30*time.Second)))
+
+	closed := aggregator.CloseExpired(start.Add(time.Minute))
+	assert.Len(t, closed, 1)
+	assert.Equal(t, domain.Interval1m, closed[0].Interval)
+
+	_, ok := aggregator.Current(domain.StockBitcoin, domain.Interval1m)
+	assert.False(t, ok)
+}
+
+func TestCandleAggregator_CloseExpiredFollowsEventTime(t *testing.T) {
+	aggregator := NewCandleAggregator(10)
+	// Replaying a day-old minute: the local clock is far ahead of the events.
+	start := time.Date(2024, 4, 27, 14, 0, 0, 0, time.UTC)
+	local := start.Add(24 * time.Hour)
+	aggregator.now = func() time.Time { return local }
+
+	aggregator.Add(tick(start, 100, 1))
+	assert.Empty(t, aggregator.CloseExpired(local.Add(30*time.Second)))
+
+	// The next tick arrives 50s later but is stamped 14:00:40.
+	aggregator.now = func() time.Time { return local.Add(50 * time.Second) }
+	aggregator.Add(tick(start.Add(40*time.Second), 110, 1))
+	assert.Empty(t, aggregator.CloseExpired(local.Add(time.Minute)), "event time is 14:00:50")
+
+	closed := aggregator.CloseExpired(local.Add(70 * time.Second))
+	assert.Len(t, closed, 1)
+	assert.Equal(t, 110.0, closed[0].Close)
+}
+
+func TestCandleAggregator_NeverReopensClosedBucket(t *testing.T) {
+	aggregator := NewCandleAggregator(10)
+	start := time.Date(2024, 4, 27, 10, 0, 0, 0, time.UTC)
+	aggregator.now = func() time.Time { return start }
+
+	aggregator.Add(tick(start, 100, 1))
+	assert.NotEmpty(t, aggregator.CloseExpired(start.Add(time.Minute)))
+
+	// Late ticks for the swept minute.
+	_, updated := aggregator.Add(tick(start.Add(10*time.Second), 90, 1))
+	for _, candle := range updated {
+		assert.NotEqual(t, domain.Interval1m, candle.Interval)
+	}
+	aggregator.Add(tick(start.Add(20*time.Second), 80, 1))
+
+	candles := aggregator.Candles(domain.StockBitcoin, domain.Interval1m, time.Time{}, time.Time{})
+	assert.Len(t, candles, 1)
+	assert.Equal(t, 100.0, candles[0].Low)
+}
+
+func TestCandleAggregator_CandlesRangeAndRetention(t *testing.T) {
+	aggregator := NewCandleAggregator(2)
+	start := time.Date(2024, 4, 27, 14, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 4; i++ {
+		aggregator.Add(tick(start.Add(time.Duration(i)*time.Minute), float64(100+i), 1))
+	}
+
+	all := aggregator.Candles(domain.StockBitcoin, domain.Interval1m, time.Time{}, time.Time{})
+	assert.Len(t, all, 3) // two retained closed candles plus the open one
+	assert.Equal(t, start.Add(time.Minute), all[0].Start)
+
+	ranged := aggregator.Candles(domain.StockBitcoin, domain.Interval1m, start.Add(2*time.Minute), start.Add(3*time.Minute))
+	assert.Len(t, ranged, 1)
+	assert.Equal(t, 102.0, ranged[0].Open)
+
+	assert.Empty(t, aggregator.Candles(domain.Stock("ETH-USD"), domain.Interval1m, time.Time{}, time.Time{}))
+}
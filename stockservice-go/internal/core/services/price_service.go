@@ -2,6 +2,8 @@ package services
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
@@ -11,18 +13,55 @@ type PriceService struct {
 	notifier ports.Notifier
 	consumer ports.Consumer
 	logger   ports.Logger
+
+	// mu orders cache updates and broadcasts against subscriptions so a new
+	// subscriber never receives a snapshot older than a tick it already got.
+	mu         sync.Mutex
+	lastPrices map[domain.Stock]*domain.PriceEvent
+	candles    *CandleAggregator
+	alerts     *AlertEngine
+	replay     *ReplayBuffer
+	sequences  *SequenceTracker
+	feedStatus domain.FeedStatus
+}
+
+const candleSweepPeriod = time.Second
+
+type Option func(*PriceService)
+
+// WithSequenceGapDetection reports every upstream sequence step other than +1
+// as a gap. Only enable it for feeds with contiguous per-product sequences.
+func WithSequenceGapDetection() Option {
+	return func(ps *PriceService) {
+		ps.sequences = NewSequenceTracker(true)
+	}
 }
 
-func NewPriceService(notifier ports.Notifier, consumer ports.Consumer, logger ports.Logger) *PriceService {
-	return &PriceService{
-		notifier: notifier,
-		consumer: consumer,
-		logger:   logger,
+func NewPriceService(notifier ports.Notifier, consumer ports.Consumer, logger ports.Logger, opts ...Option) *PriceService {
+	ps := &PriceService{
+		notifier:   notifier,
+		consumer:   consumer,
+		logger:     logger,
+		lastPrices: make(map[domain.Stock]*domain.PriceEvent),
+		candles:    NewCandleAggregator(defaultCandleRetention),
+		alerts:     NewAlertEngine(defaultMaxAlertsPerClient),
+		replay:     NewReplayBuffer(defaultReplayBufferSize),
+		sequences:  NewSequenceTracker(false),
+		feedStatus: domain.StatusDisconnected,
+	}
+	for _, opt := range opts {
+		opt(ps)
 	}
+	return ps
 }
 
 func (ps *PriceService) StartConsuming(ctx context.Context) {
-	ps.consumer.SetListener(ps.notifier.Broadcast)
+	ps.consumer.SetListener(ps.handlePriceEvent)
+	ps.consumer.SetStatusListener(ps.handleFeedStatus)
+
+	sweepCtx, stopSweep := context.WithCancel(ctx)
+	defer stopSweep()
+	go ps.sweepCandles(sweepCtx)
 
 	if err := ps.consumer.Start(ctx); err != nil {
 		ps.logger.Errorf("BitcoinPriceConsumer exited with error: %v", err)
@@ -31,26 +70,247 @@ func (ps *PriceService) StartConsuming(ctx context.Context) {
 	}
 }
 
+func (ps *PriceService) handlePriceEvent(event *domain.PriceEvent) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if event == nil {
+		return ps.notifier.Broadcast(event)
+	}
+
+	// A stale event, such as a redelivery, must not replace a newer cached
+	// price. Clients never saw it, so they are not warned either.
+	status, stale := ps.sequences.Observe(event)
+	if stale {
+		ps.logger.Infof("Dropping stale %v event: sequence %d after %d", event.ProductID, status.Sequence, status.LastSequence)
+		return nil
+	}
+
+	// Warn subscribers before delivering the event that revealed the problem.
+	if status != nil {
+		ps.logger.Errorf("Upstream %v for %v: sequence %d after %d", status.Status, event.ProductID, status.Sequence, status.LastSequence)
+		if err := ps.notifier.BroadcastStatus(status); err != nil {
+			ps.logger.Errorf("error broadcasting %v status: %v", event.ProductID, err)
+		}
+	}
+
+	ps.lastPrices[event.ProductID] = event
+	ps.replay.Add(event)
+	if err := ps.notifier.Broadcast(event); err != nil {
+		return err
+	}
+
+	closed, updated := ps.candles.Add(event)
+	ps.broadcastCandles(closed)
+	ps.broadcastCandles(updated)
+
+	for _, firing := range ps.alerts.Evaluate(event) {
+		if err := ps.notifier.Send(firing.WS, firing.Message); err != nil {
+			ps.logger.Errorf("error sending alert %v to Client %v: %v", firing.Message.Alert.ID, firing.WS.RemoteAddr(), err)
+		}
+	}
+	return nil
+}
+
+// handleFeedStatus records the state of the upstream connection and tells
+// every client about it.
+func (ps *PriceService) handleFeedStatus(status domain.FeedStatus) {
+	ps.mu.Lock()
+	ps.feedStatus = status
+	ps.mu.Unlock()
+
+	message := &domain.StatusMessage{Type: domain.StatusType, Status: status, Message: feedStatusMessages[status]}
+	if err := ps.notifier.BroadcastStatus(message); err != nil {
+		ps.logger.Errorf("error broadcasting feed status %v: %v", status, err)
+	}
+}
+
+var feedStatusMessages = map[domain.FeedStatus]string{
+	domain.StatusConnected:    "price feed connected",
+	domain.StatusDegraded:     "price feed is retrying upstream reads; prices may be delayed",
+	domain.StatusDisconnected: "price feed disconnected from upstream; prices are not updating",
+}
+
+// FeedStatus returns the last reported state of the upstream connection.
+func (ps *PriceService) FeedStatus() domain.FeedStatus {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.feedStatus
+}
+
+// sweepCandles closes candles whose interval has ended even when no new tick
+// arrives for their stock.
+func (ps *PriceService) sweepCandles(ctx context.Context) {
+	ticker := time.NewTicker(candleSweepPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			ps.mu.Lock()
+			ps.broadcastCandles(ps.candles.CloseExpired(now))
+			ps.mu.Unlock()
+		}
+	}
+}
+
+func (ps *PriceService) broadcastCandles(candles []domain.Candle) {
+	for i := range candles {
+		if err := ps.notifier.BroadcastCandle(&candles[i]); err != nil {
+			ps.logger.Errorf("error broadcasting %v %v candle: %v", candles[i].Stock, candles[i].Interval, err)
+		}
+	}
+}
+
+// LatestPrice returns the most recent event received for stock.
+func (ps *PriceService) LatestPrice(stock domain.Stock) (*domain.PriceEvent, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	last, ok := ps.lastPrices[stock]
+	if !ok {
+		return nil, false
+	}
+	event := *last
+	return &event, true
+}
+
+func (ps *PriceService) SubscribeCandles(ws ports.WebSocketConn, stock domain.Stock, interval domain.CandleInterval, ack *domain.AckMessage) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if err := ps.notifier.SubscribeCandles(ws, stock, interval); err != nil {
+		ps.logger.Errorf("error subscribing Client %v to candles: %v", ws.RemoteAddr(), err)
+		return err
+	}
+	ps.sendAck(ws, ack)
+
+	if current, ok := ps.candles.Current(stock, interval); ok {
+		message := domain.CandleMessage{Type: domain.CandleUpdateType, Candle: &current}
+		if err := ps.notifier.Send(ws, message); err != nil {
+			ps.logger.Errorf("error sending %v %v candle to Client %v: %v", stock, interval, ws.RemoteAddr(), err)
+		}
+	}
+	return nil
+}
+
+func (ps *PriceService) UnsubscribeCandles(ws ports.WebSocketConn, stock domain.Stock, interval domain.CandleInterval) error {
+	err := ps.notifier.UnsubscribeCandles(ws, stock, interval)
+	if err != nil {
+		ps.logger.Errorf("error unsubscribing Client %v from candles: %v", ws.RemoteAddr(), err)
+	}
+	return err
+}
+
+// Candles returns the candles for stock and interval starting in [from, to).
+func (ps *PriceService) Candles(stock domain.Stock, interval domain.CandleInterval, from, to time.Time) []domain.Candle {
+	return ps.candles.Candles(stock, interval, from, to)
+}
+
+// CreateAlert registers a price alert owned by ws. Crossing alerts are
+// measured from the latest cached price, so an alert created above its
+// threshold waits for the price to come back down and cross again.
+func (ps *PriceService) CreateAlert(ws ports.WebSocketConn, stock domain.Stock, spec domain.AlertSpec) (domain.Alert, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	alert, err := ps.alerts.Create(ws, stock, spec, ps.lastPrices[stock])
+	if err != nil {
+		return domain.Alert{}, err
+	}
+	ps.logger.Infof("Client %v created alert %v on %v", ws.RemoteAddr(), alert.ID, stock)
+	return alert, nil
+}
+
+func (ps *PriceService) DeleteAlert(ws ports.WebSocketConn, id string) error {
+	return ps.alerts.Delete(ws, id)
+}
+
+func (ps *PriceService) Alerts(ws ports.WebSocketConn) []domain.Alert {
+	return ps.alerts.Alerts(ws)
+}
+
 func (ps *PriceService) AddClient(ws ports.WebSocketConn) {
 	ps.notifier.AddClient(ws)
 }
 
 func (ps *PriceService) RemoveClient(ws ports.WebSocketConn) {
 	ps.notifier.RemoveClient(ws)
+	ps.alerts.RemoveClient(ws)
 }
 
-func (ps *PriceService) Subscribe(ws ports.WebSocketConn, stock domain.Stock) error {
-	err := ps.notifier.Subscribe(ws, stock)
+func (ps *PriceService) Subscribe(ws ports.WebSocketConn, stock domain.Stock, opts domain.SubscriptionOptions) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ack := opts.Ack
+	opts.Ack = nil
+	err := ps.notifier.Subscribe(ws, stock, opts)
 	if err != nil {
-		ps.logger.Errorf("error subscribing Client: %v", ws.RemoteAddr())
+		ps.logger.Errorf("error subscribing Client %v: %v", ws.RemoteAddr(), err)
+		return err
+	}
+	ps.sendAck(ws, ack)
+
+	last, ok := ps.lastPrices[stock]
+	if !ok {
+		return nil
+	}
+	// Sequences only matter when resuming; events without one still get a
+	// snapshot on a fresh subscription.
+	if opts.ResumeFrom > 0 && last.Sequence <= opts.ResumeFrom {
+		return nil
+	}
+
+	if opts.ResumeFrom > 0 {
+		if missed, covered := ps.replay.Since(stock, opts.ResumeFrom); covered {
+			for _, event := range missed {
+				if err := ps.notifier.SendEvent(ws, event); err != nil {
+					ps.logger.Errorf("error replaying %v to Client %v: %v", stock, ws.RemoteAddr(), err)
+					break
+				}
+			}
+			return nil
+		}
+		gap := domain.ResumeGapMessage{
+			Type:       domain.ResumeGapType,
+			Stock:      stock,
+			ResumeFrom: opts.ResumeFrom,
+			Message:    "gap too large, snapshot follows",
+		}
+		if err := ps.notifier.Send(ws, gap); err != nil {
+			ps.logger.Errorf("error sending %v resume gap to Client %v: %v", stock, ws.RemoteAddr(), err)
+		}
+	}
+
+	snapshot := *last
+	snapshot.Snapshot = true
+	if err := ps.notifier.SendEvent(ws, &snapshot); err != nil {
+		ps.logger.Errorf("error sending %v snapshot to Client %v: %v", stock, ws.RemoteAddr(), err)
 	}
 	return nil
 }
 
+// sendAck queues ack, if any, ahead of the data a subscription sends first.
+func (ps *PriceService) sendAck(ws ports.WebSocketConn, ack *domain.AckMessage) {
+	if ack == nil {
+		return
+	}
+	if err := ps.notifier.Send(ws, *ack); err != nil {
+		ps.logger.Errorf("error sending ack to Client %v: %v", ws.RemoteAddr(), err)
+	}
+}
+
 func (ps *PriceService) Unsubscribe(ws ports.WebSocketConn, stock domain.Stock) error {
 	err := ps.notifier.Unsubscribe(ws, stock)
 	if err != nil {
-		ps.logger.Errorf("error unsubscribing Client: %v", ws.RemoteAddr())
+		ps.logger.Errorf("error unsubscribing Client %v: %v", ws.RemoteAddr(), err)
 	}
-	return nil
+	return err
+}
+
+func (ps *PriceService) Send(ws ports.WebSocketConn, message interface{}) error {
+	return ps.notifier.Send(ws, message)
 }
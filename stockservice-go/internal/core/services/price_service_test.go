@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/mocks"
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/testutils"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 )
@@ -30,6 +32,7 @@ func TestPriceService_StartConsuming_WithError(t *testing.T) {
 	startErr := errors.New("consumer failed to start")
 
 	mockConsumer.EXPECT().SetListener(gomock.Any())
+	mockConsumer.EXPECT().SetStatusListener(gomock.Any())
 	mockConsumer.EXPECT().Start(ctx).Return(startErr)
 
 	priceService.StartConsuming(ctx)
@@ -42,6 +45,7 @@ func TestPriceService_StartConsuming_WithNoError(t *testing.T) {
 	ctx := context.Background()
 
 	mockConsumer.EXPECT().SetListener(gomock.Any())
+	mockConsumer.EXPECT().SetStatusListener(gomock.Any())
 	mockConsumer.EXPECT().Start(ctx).Return(nil)
 
 	priceService.StartConsuming(ctx)
@@ -70,9 +74,9 @@ func TestPriceService_Subscribe(t *testing.T) {
 	defer ctrl.Finish()
 
 	stock := domain.Stock("BTC-USD")
-	mockNotifier.EXPECT().Subscribe(mockConn, stock).Return(nil)
+	mockNotifier.EXPECT().Subscribe(mockConn, stock, domain.SubscriptionOptions{}).Return(nil)
 
-	err := priceService.Subscribe(mockConn, stock)
+	err := priceService.Subscribe(mockConn, stock, domain.SubscriptionOptions{})
 	assert.NoError(t, err)
 }
 
@@ -86,3 +90,409 @@ func TestPriceService_Unsubscribe(t *testing.T) {
 	err := priceService.Unsubscribe(mockConn, stock)
 	assert.NoError(t, err)
 }
+
+func TestPriceService_HandlePriceEvent_BroadcastsEvent(t *testing.T) {
+	ctrl, mockNotifier, _, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	event := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(event).Return(nil)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+
+	err := priceService.handlePriceEvent(event)
+	assert.NoError(t, err)
+}
+
+func TestPriceService_Subscribe_SendsSnapshot(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	event := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(event).Return(nil)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	assert.NoError(t, priceService.handlePriceEvent(event))
+
+	expectedSnapshot := *event
+	expectedSnapshot.Snapshot = true
+	gomock.InOrder(
+		mockNotifier.EXPECT().Subscribe(mockConn, event.ProductID, domain.SubscriptionOptions{}).Return(nil),
+		mockNotifier.EXPECT().SendEvent(mockConn, &expectedSnapshot).Return(nil),
+	)
+
+	err := priceService.Subscribe(mockConn, event.ProductID, domain.SubscriptionOptions{})
+	assert.NoError(t, err)
+	assert.False(t, event.Snapshot, "cached event must not be mutated")
+}
+
+func TestPriceService_Subscribe_SendsAckBeforeSnapshot(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	event := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(event).Return(nil)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	assert.NoError(t, priceService.handlePriceEvent(event))
+
+	ack := &domain.AckMessage{Type: "ack", ID: "req-1", Action: domain.Subscribe, Stock: event.ProductID}
+	gomock.InOrder(
+		// The ack is not part of the notifier subscription.
+		mockNotifier.EXPECT().Subscribe(mockConn, event.ProductID, domain.SubscriptionOptions{}).Return(nil),
+		mockNotifier.EXPECT().Send(mockConn, *ack).Return(nil),
+		mockNotifier.EXPECT().SendEvent(mockConn, gomock.Any()).Return(nil),
+	)
+
+	err := priceService.Subscribe(mockConn, event.ProductID, domain.SubscriptionOptions{Ack: ack})
+	assert.NoError(t, err)
+}
+
+func TestPriceService_Subscribe_SendsAckBeforeReplay(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	mockNotifier.EXPECT().Broadcast(gomock.Any()).Return(nil).Times(2)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	var last *domain.PriceEvent
+	for seq := int64(100); seq <= 101; seq++ {
+		last = testutils.CreateValidPriceEvent()
+		last.Sequence = seq
+		assert.NoError(t, priceService.handlePriceEvent(last))
+	}
+
+	ack := &domain.AckMessage{Type: "ack", Action: domain.Subscribe, Stock: domain.StockBitcoin}
+	gomock.InOrder(
+		mockNotifier.EXPECT().Subscribe(mockConn, domain.StockBitcoin, domain.SubscriptionOptions{ResumeFrom: 100}).Return(nil),
+		mockNotifier.EXPECT().Send(mockConn, *ack).Return(nil),
+		mockNotifier.EXPECT().SendEvent(mockConn, last).Return(nil),
+	)
+
+	err := priceService.Subscribe(mockConn, domain.StockBitcoin, domain.SubscriptionOptions{ResumeFrom: 100, Ack: ack})
+	assert.NoError(t, err)
+}
+
+func TestPriceService_Subscribe_SendsSnapshotOfUnsequencedEvent(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	event := &domain.PriceEvent{ProductID: domain.StockBitcoin, Price: 1}
+	mockNotifier.EXPECT().Broadcast(event).Return(nil)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	assert.NoError(t, priceService.handlePriceEvent(event))
+
+	expectedSnapshot := *event
+	expectedSnapshot.Snapshot = true
+	gomock.InOrder(
+		mockNotifier.EXPECT().Subscribe(mockConn, domain.StockBitcoin, domain.SubscriptionOptions{}).Return(nil),
+		mockNotifier.EXPECT().SendEvent(mockConn, &expectedSnapshot).Return(nil),
+	)
+
+	err := priceService.Subscribe(mockConn, domain.StockBitcoin, domain.SubscriptionOptions{})
+	assert.NoError(t, err)
+}
+
+func TestPriceService_Subscribe_NoSnapshotWithoutCachedPrice(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	stock := domain.Stock("BTC-USD")
+	mockNotifier.EXPECT().Subscribe(mockConn, stock, domain.SubscriptionOptions{}).Return(nil)
+	mockNotifier.EXPECT().SendEvent(gomock.Any(), gomock.Any()).Times(0)
+
+	err := priceService.Subscribe(mockConn, stock, domain.SubscriptionOptions{})
+	assert.NoError(t, err)
+}
+
+func TestPriceService_Send(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	message := domain.ErrorMessage{Type: "error", Message: "Unknown action"}
+	mockNotifier.EXPECT().Send(mockConn, message).Return(nil)
+
+	err := priceService.Send(mockConn, message)
+	assert.NoError(t, err)
+}
+
+func TestPriceService_Subscribe_ReturnsNotifierError(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	stock := domain.Stock("BTC-USD")
+	subscribeErr := errors.New("subscribe failed")
+	mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	mockNotifier.EXPECT().Subscribe(mockConn, stock, domain.SubscriptionOptions{}).Return(subscribeErr)
+
+	err := priceService.Subscribe(mockConn, stock, domain.SubscriptionOptions{})
+	assert.Equal(t, subscribeErr, err)
+}
+
+func TestPriceService_Unsubscribe_ReturnsNotifierError(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	stock := domain.Stock("BTC-USD")
+	unsubscribeErr := errors.New("unsubscribe failed")
+	mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	mockNotifier.EXPECT().Unsubscribe(mockConn, stock).Return(unsubscribeErr)
+
+	err := priceService.Unsubscribe(mockConn, stock)
+	assert.Equal(t, unsubscribeErr, err)
+}
+
+func TestPriceService_Subscribe_SkipsSnapshotAlreadySeen(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	event := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(event).Return(nil)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	assert.NoError(t, priceService.handlePriceEvent(event))
+
+	opts := domain.SubscriptionOptions{ResumeFrom: event.Sequence}
+	mockNotifier.EXPECT().Subscribe(mockConn, event.ProductID, opts).Return(nil)
+	mockNotifier.EXPECT().SendEvent(gomock.Any(), gomock.Any()).Times(0)
+
+	err := priceService.Subscribe(mockConn, event.ProductID, opts)
+	assert.NoError(t, err)
+}
+
+func TestPriceService_Subscribe_ReplaysMissedEvents(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	mockNotifier.EXPECT().Broadcast(gomock.Any()).Return(nil).Times(3)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	var events []*domain.PriceEvent
+	for seq := int64(100); seq <= 102; seq++ {
+		event := testutils.CreateValidPriceEvent()
+		event.Sequence = seq
+		events = append(events, event)
+		assert.NoError(t, priceService.handlePriceEvent(event))
+	}
+
+	opts := domain.SubscriptionOptions{ResumeFrom: 100}
+	gomock.InOrder(
+		mockNotifier.EXPECT().Subscribe(mockConn, domain.StockBitcoin, opts).Return(nil),
+		mockNotifier.EXPECT().SendEvent(mockConn, events[1]).Return(nil),
+		mockNotifier.EXPECT().SendEvent(mockConn, events[2]).Return(nil),
+	)
+
+	err := priceService.Subscribe(mockConn, domain.StockBitcoin, opts)
+	assert.NoError(t, err)
+}
+
+func TestPriceService_Subscribe_ReportsGapBeforeSnapshot(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	priceService.replay = NewReplayBuffer(2)
+	mockNotifier.EXPECT().Broadcast(gomock.Any()).Return(nil).Times(3)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	var last *domain.PriceEvent
+	for seq := int64(100); seq <= 102; seq++ {
+		last = testutils.CreateValidPriceEvent()
+		last.Sequence = seq
+		assert.NoError(t, priceService.handlePriceEvent(last))
+	}
+
+	opts := domain.SubscriptionOptions{ResumeFrom: 100}
+	expectedSnapshot := *last
+	expectedSnapshot.Snapshot = true
+	gomock.InOrder(
+		mockNotifier.EXPECT().Subscribe(mockConn, domain.StockBitcoin, opts).Return(nil),
+		mockNotifier.EXPECT().Send(mockConn, domain.ResumeGapMessage{
+			Type:       domain.ResumeGapType,
+			Stock:      domain.StockBitcoin,
+			ResumeFrom: 100,
+			Message:    "gap too large, snapshot follows",
+		}).Return(nil),
+		mockNotifier.EXPECT().SendEvent(mockConn, &expectedSnapshot).Return(nil),
+	)
+
+	err := priceService.Subscribe(mockConn, domain.StockBitcoin, opts)
+	assert.NoError(t, err)
+}
+
+func TestPriceService_LatestPrice(t *testing.T) {
+	ctrl, mockNotifier, _, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	_, ok := priceService.LatestPrice(domain.StockBitcoin)
+	assert.False(t, ok)
+
+	event := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(event).Return(nil)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	assert.NoError(t, priceService.handlePriceEvent(event))
+
+	latest, ok := priceService.LatestPrice(domain.StockBitcoin)
+	assert.True(t, ok)
+	assert.Equal(t, event, latest)
+	assert.NotSame(t, event, latest, "callers must get a copy of the cached event")
+}
+
+func TestPriceService_HandlePriceEvent_BroadcastsCandles(t *testing.T) {
+	ctrl, mockNotifier, _, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	event := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(event).Return(nil)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).Times(len(domain.CandleIntervals))
+
+	assert.NoError(t, priceService.handlePriceEvent(event))
+}
+
+func TestPriceService_SubscribeCandles_SendsCurrentCandle(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	event := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(event).Return(nil)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	assert.NoError(t, priceService.handlePriceEvent(event))
+
+	gomock.InOrder(
+		mockNotifier.EXPECT().SubscribeCandles(mockConn, event.ProductID, domain.Interval1m).Return(nil),
+		mockNotifier.EXPECT().Send(mockConn, gomock.Cond(func(x any) bool {
+			msg, ok := x.(domain.CandleMessage)
+			return ok && msg.Type == domain.CandleUpdateType && msg.Candle.Close == event.Price
+		})).Return(nil),
+	)
+
+	assert.NoError(t, priceService.SubscribeCandles(mockConn, event.ProductID, domain.Interval1m, nil))
+}
+
+func TestPriceService_SubscribeCandles_SendsAckBeforeCurrentCandle(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	event := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(event).Return(nil)
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	assert.NoError(t, priceService.handlePriceEvent(event))
+
+	ack := &domain.AckMessage{Type: "ack", ID: "req-1", Action: domain.Subscribe, Stock: event.ProductID, Channel: domain.ChannelCandles, Interval: domain.Interval1m}
+	gomock.InOrder(
+		mockNotifier.EXPECT().SubscribeCandles(mockConn, event.ProductID, domain.Interval1m).Return(nil),
+		mockNotifier.EXPECT().Send(mockConn, *ack).Return(nil),
+		mockNotifier.EXPECT().Send(mockConn, gomock.AssignableToTypeOf(domain.CandleMessage{})).Return(nil),
+	)
+
+	assert.NoError(t, priceService.SubscribeCandles(mockConn, event.ProductID, domain.Interval1m, ack))
+}
+
+func TestPriceService_HandlePriceEvent_SendsFiredAlerts(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	mockNotifier.EXPECT().Broadcast(gomock.Any()).Return(nil).AnyTimes()
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+
+	event := testutils.CreateValidPriceEvent()
+	below := *event
+	below.Price = event.Price - 1
+	below.Sequence = event.Sequence - 1
+	assert.NoError(t, priceService.handlePriceEvent(&below))
+
+	alert, err := priceService.CreateAlert(mockConn, event.ProductID, domain.AlertSpec{Condition: domain.CrossesAbove, Threshold: event.Price})
+	assert.NoError(t, err)
+
+	mockNotifier.EXPECT().Send(mockConn, domain.AlertMessage{
+		Type:  domain.AlertTriggeredType,
+		Alert: alert,
+		Price: event.Price,
+		Time:  event.Time,
+	}).Return(nil)
+
+	assert.NoError(t, priceService.handlePriceEvent(event))
+}
+
+func TestPriceService_HandlePriceEvent_IgnoresSequenceGapsByDefault(t *testing.T) {
+	ctrl, mockNotifier, _, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	first := testutils.CreateValidPriceEvent()
+	next := *first
+	next.Sequence = first.Sequence + 5
+	mockNotifier.EXPECT().Broadcast(first).Return(nil)
+	mockNotifier.EXPECT().Broadcast(&next).Return(nil)
+
+	assert.NoError(t, priceService.handlePriceEvent(first))
+	assert.NoError(t, priceService.handlePriceEvent(&next))
+}
+
+func TestPriceService_HandlePriceEvent_DropsStaleEvent(t *testing.T) {
+	ctrl, mockNotifier, _, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	latest := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(latest).Return(nil)
+	assert.NoError(t, priceService.handlePriceEvent(latest))
+
+	stale := *latest
+	stale.Sequence = latest.Sequence - 3
+	stale.Time = latest.Time.Add(-time.Second)
+	stale.Price = latest.Price - 10
+
+	assert.NoError(t, priceService.handlePriceEvent(&stale))
+
+	cached, ok := priceService.LatestPrice(latest.ProductID)
+	assert.True(t, ok)
+	assert.Equal(t, latest.Price, cached.Price)
+}
+
+func TestPriceService_HandlePriceEvent_BroadcastsSequenceGap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockNotifier := mocks.NewMockNotifier(ctrl)
+	priceService := NewPriceService(mockNotifier, mocks.NewMockConsumer(ctrl), &mocks.StubLogger{}, WithSequenceGapDetection())
+
+	mockNotifier.EXPECT().BroadcastCandle(gomock.Any()).Return(nil).AnyTimes()
+	first := testutils.CreateValidPriceEvent()
+	mockNotifier.EXPECT().Broadcast(first).Return(nil)
+	assert.NoError(t, priceService.handlePriceEvent(first))
+
+	next := *first
+	next.Sequence = first.Sequence + 5
+	gomock.InOrder(
+		mockNotifier.EXPECT().BroadcastStatus(gomock.Cond(func(x any) bool {
+			status := x.(*domain.StatusMessage)
+			return status.Status == domain.StatusSequenceGap && status.Stock == first.ProductID && status.Missed == 4
+		})).Return(nil),
+		mockNotifier.EXPECT().Broadcast(&next).Return(nil),
+	)
+
+	assert.NoError(t, priceService.handlePriceEvent(&next))
+}
+
+func TestPriceService_HandleFeedStatus(t *testing.T) {
+	ctrl, mockNotifier, _, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	assert.Equal(t, domain.StatusDisconnected, priceService.FeedStatus())
+
+	mockNotifier.EXPECT().BroadcastStatus(gomock.Cond(func(x any) bool {
+		status := x.(*domain.StatusMessage)
+		return status.Type == domain.StatusType && status.Status == domain.StatusDegraded && status.Stock == ""
+	})).Return(nil)
+
+	priceService.handleFeedStatus(domain.StatusDegraded)
+
+	assert.Equal(t, domain.StatusDegraded, priceService.FeedStatus())
+}
+
+func TestPriceService_RemoveClient_RemovesAlerts(t *testing.T) {
+	ctrl, mockNotifier, mockConn, _, priceService := setup(t)
+	defer ctrl.Finish()
+
+	mockConn.EXPECT().RemoteAddr().Return(nil).AnyTimes()
+	_, err := priceService.CreateAlert(mockConn, domain.StockBitcoin, domain.AlertSpec{Condition: domain.CrossesAbove, Threshold: 1})
+	assert.NoError(t, err)
+
+	mockNotifier.EXPECT().RemoveClient(mockConn)
+	priceService.RemoveClient(mockConn)
+
+	assert.Empty(t, priceService.Alerts(mockConn))
+}
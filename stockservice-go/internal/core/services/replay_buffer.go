@@ -0,0 +1,81 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+)
+
+const defaultReplayBufferSize = 1000
+
+// eventRing holds the most recent events of one stock in arrival order.
+type eventRing struct {
+	events []*domain.PriceEvent
+	next   int
+	full   bool
+}
+
+// ReplayBuffer keeps a bounded window of recent events per stock so that
+// reconnecting clients can catch up on what they missed.
+type ReplayBuffer struct {
+	mu    sync.Mutex
+	size  int
+	rings map[domain.Stock]*eventRing
+}
+
+func NewReplayBuffer(size int) *ReplayBuffer {
+	if size <= 0 {
+		size = defaultReplayBufferSize
+	}
+	return &ReplayBuffer{
+		size:  size,
+		rings: make(map[domain.Stock]*eventRing),
+	}
+}
+
+// Add records event, evicting the oldest event of its stock when full.
+func (b *ReplayBuffer) Add(event *domain.PriceEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ring, ok := b.rings[event.ProductID]
+	if !ok {
+		ring = &eventRing{events: make([]*domain.PriceEvent, b.size)}
+		b.rings[event.ProductID] = ring
+	}
+	ring.events[ring.next] = event
+	ring.next = (ring.next + 1) % b.size
+	if ring.next == 0 {
+		ring.full = true
+	}
+}
+
+// Since returns the buffered events of stock with a Sequence after sequence,
+// oldest first. ok is false when the buffer no longer reaches back to
+// sequence, so events the client missed may have been evicted. Upstream
+// sequences are not contiguous, so coverage is only certain when the event
+// the client last saw, or an older one, is still buffered.
+func (b *ReplayBuffer) Since(stock domain.Stock, sequence int64) (events []*domain.PriceEvent, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ring, found := b.rings[stock]
+	if !found {
+		return nil, false
+	}
+
+	start, count := 0, ring.next
+	if ring.full {
+		start, count = ring.next, b.size
+	}
+	for i := 0; i < count; i++ {
+		event := ring.events[(start+i)%b.size]
+		if i == 0 {
+			ok = event.Sequence <= sequence
+		}
+		if event.Sequence > sequence {
+			events = append(events, event)
+		}
+	}
+	return events, ok
+}
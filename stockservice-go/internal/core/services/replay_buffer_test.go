@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func sequences(events []*domain.PriceEvent) []int64 {
+	var seqs []int64
+	for _, event := range events {
+		seqs = append(seqs, event.Sequence)
+	}
+	return seqs
+}
+
+func TestReplayBuffer_SinceReturnsMissedEvents(t *testing.T) {
+	buffer := NewReplayBuffer(10)
+	for _, seq := range []int64{10, 12, 15, 20} {
+		buffer.Add(&domain.PriceEvent{ProductID: domain.StockBitcoin, Sequence: seq})
+	}
+
+	events, ok := buffer.Since(domain.StockBitcoin, 12)
+
+	assert.True(t, ok)
+	assert.Equal(t, []int64{15, 20}, sequences(events))
+}
+
+func TestReplayBuffer_SinceAfterWrapKeepsOrder(t *testing.T) {
+	buffer := NewReplayBuffer(3)
+	for seq := int64(1); seq <= 5; seq++ {
+		buffer.Add(&domain.PriceEvent{ProductID: domain.StockBitcoin, Sequence: seq})
+	}
+
+	events, ok := buffer.Since(domain.StockBitcoin, 3)
+
+	assert.True(t, ok)
+	assert.Equal(t, []int64{4, 5}, sequences(events))
+}
+
+func TestReplayBuffer_SinceReportsEvictedRange(t *testing.T) {
+	buffer := NewReplayBuffer(3)
+	for seq := int64(1); seq <= 5; seq++ {
+		buffer.Add(&domain.PriceEvent{ProductID: domain.StockBitcoin, Sequence: seq})
+	}
+
+	_, ok := buffer.Since(domain.StockBitcoin, 1)
+
+	assert.False(t, ok, "sequence 2 was evicted")
+}
+
+func TestReplayBuffer_SinceUnknownStock(t *testing.T) {
+	buffer := NewReplayBuffer(3)
+	buffer.Add(&domain.PriceEvent{ProductID: domain.StockBitcoin, Sequence: 1})
+
+	events, ok := buffer.Since(domain.Stock("ETH-USD"), 0)
+
+	assert.False(t, ok)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+)
+
+// Per-product counters, published at /debug/vars.
+var (
+	sequenceGaps        = expvar.NewMap("feed_sequence_gaps")
+	sequenceMissed      = expvar.NewMap("feed_sequence_missed")
+	sequenceRegressions = expvar.NewMap("feed_sequence_regressions")
+)
+
+// SequenceTracker follows the upstream sequence number of each product and
+// reports regressions, and gaps when enabled.
+type SequenceTracker struct {
+	// detectGaps reports any step other than +1 as a gap. Only feeds whose
+	// sequences are contiguous per product can enable it: Coinbase ticker
+	// sequences are shared across channels and skip between ticks.
+	detectGaps bool
+
+	mu   sync.Mutex
+	last map[domain.Stock]observed
+}
+
+type observed struct {
+	sequence int64
+	time     time.Time
+}
+
+func NewSequenceTracker(detectGaps bool) *SequenceTracker {
+	return &SequenceTracker{detectGaps: detectGaps, last: make(map[domain.Stock]observed)}
+}
+
+// Observe records the sequence of event and returns a status message when it
+// does not follow the previous one. Events without a sequence are ignored.
+//
+// A regression is stale when the event is also no newer than the previous
+// one, as with a redelivery: the event should be dropped and tracking stays
+// where it was. Otherwise it is taken as an upstream reset and tracking
+// restarts from the new sequence, so the reset is reported once rather than
+// on every following event.
+func (t *SequenceTracker) Observe(event *domain.PriceEvent) (status *domain.StatusMessage, stale bool) {
+	if event.Sequence <= 0 {
+		return nil, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stock := event.ProductID
+	last, seen := t.last[stock]
+	if seen && event.Sequence <= last.sequence {
+		sequenceRegressions.Add(string(stock), 1)
+		stale = !event.Time.After(last.time)
+		if !stale {
+			t.last[stock] = observed{sequence: event.Sequence, time: event.Time}
+		}
+		return &domain.StatusMessage{
+			Type:         domain.StatusType,
+			Status:       domain.StatusSequenceRegression,
+			Stock:        stock,
+			LastSequence: last.sequence,
+			Sequence:     event.Sequence,
+			Message:      "upstream sequence went backwards, data may be duplicated or out of order",
+		}, stale
+	}
+
+	t.last[stock] = observed{sequence: event.Sequence, time: event.Time}
+	if !seen || !t.detectGaps || event.Sequence == last.sequence+1 {
+		return nil, false
+	}
+
+	missed := event.Sequence - last.sequence - 1
+	sequenceGaps.Add(string(stock), 1)
+	sequenceMissed.Add(string(stock), missed)
+	return &domain.StatusMessage{
+		Type:         domain.StatusType,
+		Status:       domain.StatusSequenceGap,
+		Stock:        stock,
+		LastSequence: last.sequence,
+		Sequence:     event.Sequence,
+		Missed:       missed,
+		Message:      fmt.Sprintf("%d upstream messages missed, data may be incomplete", missed),
+	}, false
+}
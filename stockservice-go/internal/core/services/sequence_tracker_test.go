@@ -0,0 +1,101 @@
+package services
+
+import (
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var sequenceBase = time.Date(2024, 4, 27, 10, 0, 0, 0, time.UTC)
+
+// eventWithSequence returns an event whose time advances with seq.
+func eventWithSequence(seq int64) *domain.PriceEvent {
+	return &domain.PriceEvent{ProductID: domain.StockBitcoin, Sequence: seq, Time: sequenceBase.Add(time.Duration(seq) * time.Second)}
+}
+
+func TestSequenceTracker_InOrder(t *testing.T) {
+	tracker := NewSequenceTracker(true)
+
+	for _, event := range []*domain.PriceEvent{
+		eventWithSequence(1),
+		eventWithSequence(2),
+		{ProductID: "ETH-USD", Sequence: 50},
+		eventWithSequence(0),
+		eventWithSequence(3),
+	} {
+		status, stale := tracker.Observe(event)
+		assert.Nil(t, status)
+		assert.False(t, stale)
+	}
+}
+
+func TestSequenceTracker_GapsIgnoredByDefault(t *testing.T) {
+	tracker := NewSequenceTracker(false)
+
+	tracker.Observe(eventWithSequence(10))
+	status, stale := tracker.Observe(eventWithSequence(14))
+
+	assert.Nil(t, status, "ticker sequences skip between ticks")
+	assert.False(t, stale)
+}
+
+func TestSequenceTracker_Gap(t *testing.T) {
+	tracker := NewSequenceTracker(true)
+	missed := func() int64 {
+		if v, ok := sequenceMissed.Get(string(domain.StockBitcoin)).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	before := missed()
+
+	tracker.Observe(eventWithSequence(10))
+	status, _ := tracker.Observe(eventWithSequence(14))
+
+	assert.Equal(t, &domain.StatusMessage{
+		Type:         domain.StatusType,
+		Status:       domain.StatusSequenceGap,
+		Stock:        domain.StockBitcoin,
+		LastSequence: 10,
+		Sequence:     14,
+		Missed:       3,
+		Message:      "3 upstream messages missed, data may be incomplete",
+	}, status)
+	assert.Equal(t, before+3, missed())
+}
+
+func TestSequenceTracker_StaleEventKeepsTracking(t *testing.T) {
+	tracker := NewSequenceTracker(false)
+
+	tracker.Observe(eventWithSequence(10))
+	status, stale := tracker.Observe(eventWithSequence(8))
+
+	assert.Equal(t, domain.StatusSequenceRegression, status.Status)
+	assert.Equal(t, int64(10), status.LastSequence)
+	assert.True(t, stale)
+
+	status, stale = tracker.Observe(eventWithSequence(9))
+	assert.Equal(t, domain.StatusSequenceRegression, status.Status, "still behind sequence 10")
+	assert.True(t, stale)
+}
+
+func TestSequenceTracker_ResetRestartsTracking(t *testing.T) {
+	tracker := NewSequenceTracker(false)
+
+	tracker.Observe(eventWithSequence(10))
+	reset := eventWithSequence(1)
+	reset.Time = sequenceBase.Add(time.Hour)
+	status, stale := tracker.Observe(reset)
+
+	assert.Equal(t, domain.StatusSequenceRegression, status.Status)
+	assert.False(t, stale)
+
+	next := eventWithSequence(2)
+	next.Time = reset.Time.Add(time.Second)
+	status, stale = tracker.Observe(next)
+	assert.Nil(t, status)
+	assert.False(t, stale)
+}
@@ -13,6 +13,7 @@ import (
 	context "context"
 	net "net"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/domain"
 	ports "github.com/ZiyadBouazara/bitcoin-pulse/stockservice-go/internal/core/ports"
@@ -55,6 +56,78 @@ func (mr *MockPriceServiceMockRecorder) AddClient(ws any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddClient", reflect.TypeOf((*MockPriceService)(nil).AddClient), ws)
 }
 
+// Alerts mocks base method.
+func (m *MockPriceService) Alerts(ws ports.WebSocketConn) []domain.Alert {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Alerts", ws)
+	ret0, _ := ret[0].([]domain.Alert)
+	return ret0
+}
+
+// Alerts indicates an expected call of Alerts.
+func (mr *MockPriceServiceMockRecorder) Alerts(ws any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Alerts", reflect.TypeOf((*MockPriceService)(nil).Alerts), ws)
+}
+
+// Candles mocks base method.
+func (m *MockPriceService) Candles(stock domain.Stock, interval domain.CandleInterval, from, to time.Time) []domain.Candle {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Candles", stock, interval, from, to)
+	ret0, _ := ret[0].([]domain.Candle)
+	return ret0
+}
+
+// Candles indicates an expected call of Candles.
+func (mr *MockPriceServiceMockRecorder) Candles(stock, interval, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Candles", reflect.TypeOf((*MockPriceService)(nil).Candles), stock, interval, from, to)
+}
+
+// CreateAlert mocks base method.
+func (m *MockPriceService) CreateAlert(ws ports.WebSocketConn, stock domain.Stock, spec domain.AlertSpec) (domain.Alert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAlert", ws, stock, spec)
+	ret0, _ := ret[0].(domain.Alert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAlert indicates an expected call of CreateAlert.
+func (mr *MockPriceServiceMockRecorder) CreateAlert(ws, stock, spec any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAlert", reflect.TypeOf((*MockPriceService)(nil).CreateAlert), ws, stock, spec)
+}
+
+// DeleteAlert mocks base method.
+func (m *MockPriceService) DeleteAlert(ws ports.WebSocketConn, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAlert", ws, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAlert indicates an expected call of DeleteAlert.
+func (mr *MockPriceServiceMockRecorder) DeleteAlert(ws, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAlert", reflect.TypeOf((*MockPriceService)(nil).DeleteAlert), ws, id)
+}
+
+// LatestPrice mocks base method.
+func (m *MockPriceService) LatestPrice(stock domain.Stock) (*domain.PriceEvent, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatestPrice", stock)
+	ret0, _ := ret[0].(*domain.PriceEvent)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// LatestPrice indicates an expected call of LatestPrice.
+func (mr *MockPriceServiceMockRecorder) LatestPrice(stock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestPrice", reflect.TypeOf((*MockPriceService)(nil).LatestPrice), stock)
+}
+
 // RemoveClient mocks base method.
 func (m *MockPriceService) RemoveClient(ws ports.WebSocketConn) {
 	m.ctrl.T.Helper()
@@ -67,6 +140,20 @@ func (mr *MockPriceServiceMockRecorder) RemoveClient(ws any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveClient", reflect.TypeOf((*MockPriceService)(nil).RemoveClient), ws)
 }
 
+// Send mocks base method.
+func (m *MockPriceService) Send(ws ports.WebSocketConn, message any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ws, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockPriceServiceMockRecorder) Send(ws, message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockPriceService)(nil).Send), ws, message)
+}
+
 // StartConsuming mocks base method.
 func (m *MockPriceService) StartConsuming(ctx context.Context) {
 	m.ctrl.T.Helper()
@@ -80,17 +167,31 @@ func (mr *MockPriceServiceMockRecorder) StartConsuming(ctx any) *gomock.Call {
 }
 
 // Subscribe mocks base method.
-func (m *MockPriceService) Subscribe(ws ports.WebSocketConn, stock domain.Stock) error {
+func (m *MockPriceService) Subscribe(ws ports.WebSocketConn, stock domain.Stock, opts domain.SubscriptionOptions) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Subscribe", ws, stock)
+	ret := m.ctrl.Call(m, "Subscribe", ws, stock, opts)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Subscribe indicates an expected call of Subscribe.
-func (mr *MockPriceServiceMockRecorder) Subscribe(ws, stock any) *gomock.Call {
+func (mr *MockPriceServiceMockRecorder) Subscribe(ws, stock, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockPriceService)(nil).Subscribe), ws, stock, opts)
+}
+
+// SubscribeCandles mocks base method.
+func (m *MockPriceService) SubscribeCandles(ws ports.WebSocketConn, stock domain.Stock, interval domain.CandleInterval, ack *domain.AckMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeCandles", ws, stock, interval, ack)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SubscribeCandles indicates an expected call of SubscribeCandles.
+func (mr *MockPriceServiceMockRecorder) SubscribeCandles(ws, stock, interval, ack any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockPriceService)(nil).Subscribe), ws, stock)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeCandles", reflect.TypeOf((*MockPriceService)(nil).SubscribeCandles), ws, stock, interval, ack)
 }
 
 // Unsubscribe mocks base method.
@@ -107,6 +208,20 @@ func (mr *MockPriceServiceMockRecorder) Unsubscribe(ws, stock any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockPriceService)(nil).Unsubscribe), ws, stock)
 }
 
+// UnsubscribeCandles mocks base method.
+func (m *MockPriceService) UnsubscribeCandles(ws ports.WebSocketConn, stock domain.Stock, interval domain.CandleInterval) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsubscribeCandles", ws, stock, interval)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnsubscribeCandles indicates an expected call of UnsubscribeCandles.
+func (mr *MockPriceServiceMockRecorder) UnsubscribeCandles(ws, stock, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsubscribeCandles", reflect.TypeOf((*MockPriceService)(nil).UnsubscribeCandles), ws, stock, interval)
+}
+
 // MockLogger is a mock of Logger interface.
 type MockLogger struct {
 	ctrl     *gomock.Controller
@@ -234,6 +349,18 @@ func (mr *MockConsumerMockRecorder) SetListener(handlePriceEvent any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetListener", reflect.TypeOf((*MockConsumer)(nil).SetListener), handlePriceEvent)
 }
 
+// SetStatusListener mocks base method.
+func (m *MockConsumer) SetStatusListener(handleStatus func(domain.FeedStatus)) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetStatusListener", handleStatus)
+}
+
+// SetStatusListener indicates an expected call of SetStatusListener.
+func (mr *MockConsumerMockRecorder) SetStatusListener(handleStatus any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatusListener", reflect.TypeOf((*MockConsumer)(nil).SetStatusListener), handleStatus)
+}
+
 // Start mocks base method.
 func (m *MockConsumer) Start(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -248,6 +375,44 @@ func (mr *MockConsumerMockRecorder) Start(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockConsumer)(nil).Start), ctx)
 }
 
+// MockFeedHealth is a mock of FeedHealth interface.
+type MockFeedHealth struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeedHealthMockRecorder
+	isgomock struct{}
+}
+
+// MockFeedHealthMockRecorder is the mock recorder for MockFeedHealth.
+type MockFeedHealthMockRecorder struct {
+	mock *MockFeedHealth
+}
+
+// NewMockFeedHealth creates a new mock instance.
+func NewMockFeedHealth(ctrl *gomock.Controller) *MockFeedHealth {
+	mock := &MockFeedHealth{ctrl: ctrl}
+	mock.recorder = &MockFeedHealthMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeedHealth) EXPECT() *MockFeedHealthMockRecorder {
+	return m.recorder
+}
+
+// FeedStatus mocks base method.
+func (m *MockFeedHealth) FeedStatus() domain.FeedStatus {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FeedStatus")
+	ret0, _ := ret[0].(domain.FeedStatus)
+	return ret0
+}
+
+// FeedStatus indicates an expected call of FeedStatus.
+func (mr *MockFeedHealthMockRecorder) FeedStatus() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FeedStatus", reflect.TypeOf((*MockFeedHealth)(nil).FeedStatus))
+}
+
 // MockPriceEventListener is a mock of PriceEventListener interface.
 type MockPriceEventListener struct {
 	ctrl     *gomock.Controller
@@ -336,6 +501,34 @@ func (mr *MockNotifierMockRecorder) Broadcast(event any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Broadcast", reflect.TypeOf((*MockNotifier)(nil).Broadcast), event)
 }
 
+// BroadcastCandle mocks base method.
+func (m *MockNotifier) BroadcastCandle(candle *domain.Candle) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BroadcastCandle", candle)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BroadcastCandle indicates an expected call of BroadcastCandle.
+func (mr *MockNotifierMockRecorder) BroadcastCandle(candle any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BroadcastCandle", reflect.TypeOf((*MockNotifier)(nil).BroadcastCandle), candle)
+}
+
+// BroadcastStatus mocks base method.
+func (m *MockNotifier) BroadcastStatus(status *domain.StatusMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BroadcastStatus", status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BroadcastStatus indicates an expected call of BroadcastStatus.
+func (mr *MockNotifierMockRecorder) BroadcastStatus(status any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BroadcastStatus", reflect.TypeOf((*MockNotifier)(nil).BroadcastStatus), status)
+}
+
 // RemoveClient mocks base method.
 func (m *MockNotifier) RemoveClient(ws ports.WebSocketConn) {
 	m.ctrl.T.Helper()
@@ -348,18 +541,60 @@ func (mr *MockNotifierMockRecorder) RemoveClient(ws any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveClient", reflect.TypeOf((*MockNotifier)(nil).RemoveClient), ws)
 }
 
+// Send mocks base method.
+func (m *MockNotifier) Send(ws ports.WebSocketConn, message any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Send", ws, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Send indicates an expected call of Send.
+func (mr *MockNotifierMockRecorder) Send(ws, message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockNotifier)(nil).Send), ws, message)
+}
+
+// SendEvent mocks base method.
+func (m *MockNotifier) SendEvent(ws ports.WebSocketConn, event *domain.PriceEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendEvent", ws, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendEvent indicates an expected call of SendEvent.
+func (mr *MockNotifierMockRecorder) SendEvent(ws, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendEvent", reflect.TypeOf((*MockNotifier)(nil).SendEvent), ws, event)
+}
+
 // Subscribe mocks base method.
-func (m *MockNotifier) Subscribe(ws ports.WebSocketConn, stock domain.Stock) error {
+func (m *MockNotifier) Subscribe(ws ports.WebSocketConn, stock domain.Stock, opts domain.SubscriptionOptions) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Subscribe", ws, stock)
+	ret := m.ctrl.Call(m, "Subscribe", ws, stock, opts)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Subscribe indicates an expected call of Subscribe.
-func (mr *MockNotifierMockRecorder) Subscribe(ws, stock any) *gomock.Call {
+func (mr *MockNotifierMockRecorder) Subscribe(ws, stock, opts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockNotifier)(nil).Subscribe), ws, stock, opts)
+}
+
+// SubscribeCandles mocks base method.
+func (m *MockNotifier) SubscribeCandles(ws ports.WebSocketConn, stock domain.Stock, interval domain.CandleInterval) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeCandles", ws, stock, interval)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SubscribeCandles indicates an expected call of SubscribeCandles.
+func (mr *MockNotifierMockRecorder) SubscribeCandles(ws, stock, interval any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockNotifier)(nil).Subscribe), ws, stock)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeCandles", reflect.TypeOf((*MockNotifier)(nil).SubscribeCandles), ws, stock, interval)
 }
 
 // Unsubscribe mocks base method.
@@ -376,6 +611,177 @@ func (mr *MockNotifierMockRecorder) Unsubscribe(ws, stock any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockNotifier)(nil).Unsubscribe), ws, stock)
 }
 
+// UnsubscribeCandles mocks base method.
+func (m *MockNotifier) UnsubscribeCandles(ws ports.WebSocketConn, stock domain.Stock, interval domain.CandleInterval) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsubscribeCandles", ws, stock, interval)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnsubscribeCandles indicates an expected call of UnsubscribeCandles.
+func (mr *MockNotifierMockRecorder) UnsubscribeCandles(ws, stock, interval any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsubscribeCandles", reflect.TypeOf((*MockNotifier)(nil).UnsubscribeCandles), ws, stock, interval)
+}
+
+// MockClientRegistry is a mock of ClientRegistry interface.
+type MockClientRegistry struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientRegistryMockRecorder
+	isgomock struct{}
+}
+
+// MockClientRegistryMockRecorder is the mock recorder for MockClientRegistry.
+type MockClientRegistryMockRecorder struct {
+	mock *MockClientRegistry
+}
+
+// NewMockClientRegistry creates a new mock instance.
+func NewMockClientRegistry(ctrl *gomock.Controller) *MockClientRegistry {
+	mock := &MockClientRegistry{ctrl: ctrl}
+	mock.recorder = &MockClientRegistryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClientRegistry) EXPECT() *MockClientRegistryMockRecorder {
+	return m.recorder
+}
+
+// Clients mocks base method.
+func (m *MockClientRegistry) Clients() []domain.ClientInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clients")
+	ret0, _ := ret[0].([]domain.ClientInfo)
+	return ret0
+}
+
+// Clients indicates an expected call of Clients.
+func (mr *MockClientRegistryMockRecorder) Clients() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clients", reflect.TypeOf((*MockClientRegistry)(nil).Clients))
+}
+
+// Disconnect mocks base method.
+func (m *MockClientRegistry) Disconnect(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disconnect", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Disconnect indicates an expected call of Disconnect.
+func (mr *MockClientRegistryMockRecorder) Disconnect(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disconnect", reflect.TypeOf((*MockClientRegistry)(nil).Disconnect), id)
+}
+
+// DropSymbol mocks base method.
+func (m *MockClientRegistry) DropSymbol(stock domain.Stock) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DropSymbol", stock)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// DropSymbol indicates an expected call of DropSymbol.
+func (mr *MockClientRegistryMockRecorder) DropSymbol(stock any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropSymbol", reflect.TypeOf((*MockClientRegistry)(nil).DropSymbol), stock)
+}
+
+// SubscriberCounts mocks base method.
+func (m *MockClientRegistry) SubscriberCounts() map[domain.Stock]int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscriberCounts")
+	ret0, _ := ret[0].(map[domain.Stock]int)
+	return ret0
+}
+
+// SubscriberCounts indicates an expected call of SubscriberCounts.
+func (mr *MockClientRegistryMockRecorder) SubscriberCounts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscriberCounts", reflect.TypeOf((*MockClientRegistry)(nil).SubscriberCounts))
+}
+
+// MockAuthenticator is a mock of Authenticator interface.
+type MockAuthenticator struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuthenticatorMockRecorder
+	isgomock struct{}
+}
+
+// MockAuthenticatorMockRecorder is the mock recorder for MockAuthenticator.
+type MockAuthenticatorMockRecorder struct {
+	mock *MockAuthenticator
+}
+
+// NewMockAuthenticator creates a new mock instance.
+func NewMockAuthenticator(ctrl *gomock.Controller) *MockAuthenticator {
+	mock := &MockAuthenticator{ctrl: ctrl}
+	mock.recorder = &MockAuthenticatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuthenticator) EXPECT() *MockAuthenticatorMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAuthenticator) Authenticate(creds domain.Credentials) (*domain.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", creds)
+	ret0, _ := ret[0].(*domain.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAuthenticatorMockRecorder) Authenticate(creds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAuthenticator)(nil).Authenticate), creds)
+}
+
+// MockLabeledWriter is a mock of LabeledWriter interface.
+type MockLabeledWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockLabeledWriterMockRecorder
+	isgomock struct{}
+}
+
+// MockLabeledWriterMockRecorder is the mock recorder for MockLabeledWriter.
+type MockLabeledWriterMockRecorder struct {
+	mock *MockLabeledWriter
+}
+
+// NewMockLabeledWriter creates a new mock instance.
+func NewMockLabeledWriter(ctrl *gomock.Controller) *MockLabeledWriter {
+	mock := &MockLabeledWriter{ctrl: ctrl}
+	mock.recorder = &MockLabeledWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLabeledWriter) EXPECT() *MockLabeledWriterMockRecorder {
+	return m.recorder
+}
+
+// WriteLabeled mocks base method.
+func (m *MockLabeledWriter) WriteLabeled(messageType int, data []byte, label ports.MessageLabel) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteLabeled", messageType, data, label)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteLabeled indicates an expected call of WriteLabeled.
+func (mr *MockLabeledWriterMockRecorder) WriteLabeled(messageType, data, label any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteLabeled", reflect.TypeOf((*MockLabeledWriter)(nil).WriteLabeled), messageType, data, label)
+}
+
 // MockWebSocketConn is a mock of WebSocketConn interface.
 type MockWebSocketConn struct {
 	ctrl     *gomock.Controller
@@ -444,6 +850,86 @@ func (mr *MockWebSocketConnMockRecorder) RemoteAddr() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoteAddr", reflect.TypeOf((*MockWebSocketConn)(nil).RemoteAddr))
 }
 
+// SetPongHandler mocks base method.
+func (m *MockWebSocketConn) SetPongHandler(h func(string) error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPongHandler", h)
+}
+
+// SetPongHandler indicates an expected call of SetPongHandler.
+func (mr *MockWebSocketConnMockRecorder) SetPongHandler(h any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPongHandler", reflect.TypeOf((*MockWebSocketConn)(nil).SetPongHandler), h)
+}
+
+// SetReadDeadline mocks base method.
+func (m *MockWebSocketConn) SetReadDeadline(t time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReadDeadline", t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetReadDeadline indicates an expected call of SetReadDeadline.
+func (mr *MockWebSocketConnMockRecorder) SetReadDeadline(t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadDeadline", reflect.TypeOf((*MockWebSocketConn)(nil).SetReadDeadline), t)
+}
+
+// SetReadLimit mocks base method.
+func (m *MockWebSocketConn) SetReadLimit(limit int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetReadLimit", limit)
+}
+
+// SetReadLimit indicates an expected call of SetReadLimit.
+func (mr *MockWebSocketConnMockRecorder) SetReadLimit(limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadLimit", reflect.TypeOf((*MockWebSocketConn)(nil).SetReadLimit), limit)
+}
+
+// SetWriteDeadline mocks base method.
+func (m *MockWebSocketConn) SetWriteDeadline(t time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteDeadline", t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWriteDeadline indicates an expected call of SetWriteDeadline.
+func (mr *MockWebSocketConnMockRecorder) SetWriteDeadline(t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteDeadline", reflect.TypeOf((*MockWebSocketConn)(nil).SetWriteDeadline), t)
+}
+
+// Subprotocol mocks base method.
+func (m *MockWebSocketConn) Subprotocol() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subprotocol")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Subprotocol indicates an expected call of Subprotocol.
+func (mr *MockWebSocketConnMockRecorder) Subprotocol() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subprotocol", reflect.TypeOf((*MockWebSocketConn)(nil).Subprotocol))
+}
+
+// WriteControl mocks base method.
+func (m *MockWebSocketConn) WriteControl(messageType int, data []byte, deadline time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteControl", messageType, data, deadline)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteControl indicates an expected call of WriteControl.
+func (mr *MockWebSocketConnMockRecorder) WriteControl(messageType, data, deadline any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteControl", reflect.TypeOf((*MockWebSocketConn)(nil).WriteControl), messageType, data, deadline)
+}
+
 // WriteMessage mocks base method.
 func (m *MockWebSocketConn) WriteMessage(messageType int, data []byte) error {
 	m.ctrl.T.Helper()